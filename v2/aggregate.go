@@ -0,0 +1,163 @@
+package treemap
+
+import (
+	"iter"
+
+	"golang.org/x/exp/constraints"
+)
+
+// Monoid is an associative operation over values along with its identity element.
+// Combine(Identity, x) and Combine(x, Identity) must be equal to x.
+// Sum, min, max and count are monoids.
+type Monoid[Value any] struct {
+	Identity Value
+	Combine  func(a, b Value) Value
+}
+
+// AggregateMap is the generic key-sorted map keeping the monoid aggregate of every subtree.
+// Use Aggregate to combine the values of a key range.
+type AggregateMap[Key, Value any] struct {
+	m      *TreeMap[Key, aggregateEntry[Value]]
+	monoid Monoid[Value]
+}
+
+type aggregateEntry[Value any] struct {
+	value Value
+	agg   Value
+}
+
+// NewAggregateMap creates and returns new AggregateMap with the specified monoid.
+func NewAggregateMap[Key constraints.Ordered, Value any](monoid Monoid[Value]) *AggregateMap[Key, Value] {
+	return NewAggregateMapWithKeyCompare[Key, Value](defaultKeyCompare[Key], monoid)
+}
+
+// NewAggregateMapWithKeyCompare creates and returns new AggregateMap
+// with the specified key compare function and monoid.
+// Parameter keyCompare is a function returning a < b.
+func NewAggregateMapWithKeyCompare[Key, Value any](
+	keyCompare func(a, b Key) bool,
+	monoid Monoid[Value],
+) *AggregateMap[Key, Value] {
+	m := NewWithKeyCompare[Key, aggregateEntry[Value]](keyCompare)
+	m.augment = func(x *node[Key, aggregateEntry[Value]]) {
+		x.value.agg = monoid.Combine(monoid.Combine(aggOf(x.left, monoid), x.value.value), aggOf(x.right, monoid))
+	}
+	return &AggregateMap[Key, Value]{m: m, monoid: monoid}
+}
+
+// Len returns total count of elements in a map.
+// Complexity: O(1).
+func (t *AggregateMap[Key, Value]) Len() int { return t.m.Len() }
+
+// Set sets the value and silently overrides previous value if it exists.
+// Complexity: O(log N).
+func (t *AggregateMap[Key, Value]) Set(key Key, value Value) {
+	t.m.Set(key, aggregateEntry[Value]{value: value})
+}
+
+// Get retrieves a value from a map for specified key and reports if it exists.
+// Complexity: O(log N).
+func (t *AggregateMap[Key, Value]) Get(key Key) (Value, bool) {
+	e, ok := t.m.Get(key)
+	return e.value, ok
+}
+
+// Contains checks if key exists in a map.
+// Complexity: O(log N)
+func (t *AggregateMap[Key, Value]) Contains(key Key) bool { return t.m.Contains(key) }
+
+// Del deletes the value for the specified key.
+// Complexity: O(log N).
+func (t *AggregateMap[Key, Value]) Del(key Key) { t.m.Del(key) }
+
+// DelRange deletes all the keys in the range [from, to] and returns the number of deleted elements.
+// Complexity: O(log N).
+func (t *AggregateMap[Key, Value]) DelRange(from, to Key) int { return t.m.DelRange(from, to) }
+
+// Clear clears the map.
+// Complexity: O(1).
+func (t *AggregateMap[Key, Value]) Clear() { t.m.Clear() }
+
+// Clone returns a copy of the map.
+// The copy has exactly the same tree structure, so no key comparisons are made.
+// Complexity: O(N).
+func (t *AggregateMap[Key, Value]) Clone() *AggregateMap[Key, Value] {
+	return &AggregateMap[Key, Value]{m: t.m.Clone(), monoid: t.monoid}
+}
+
+// Aggregate combines the values of all the keys in the range [from, to] in ascending key order.
+// It returns the identity if there are no such keys.
+// Complexity: O(log N).
+func (t *AggregateMap[Key, Value]) Aggregate(from, to Key) Value {
+	t.m.reading()
+	return t.aggregate(t.m.endNode.left, &from, &to)
+}
+
+// AggregateAll combines the values of all the keys in ascending key order.
+// It returns the identity if a map is empty.
+// Complexity: O(1).
+func (t *AggregateMap[Key, Value]) AggregateAll() Value {
+	t.m.reading()
+	return aggOf(t.m.endNode.left, t.monoid)
+}
+
+// All returns an iterator over key-value pairs in ascending key order.
+// It is safe to modify the map in the loop body, the iteration goes on from the key following the current one.
+func (t *AggregateMap[Key, Value]) All() iter.Seq2[Key, Value] {
+	return unwrapAggregates(t.m.All())
+}
+
+// Backward returns an iterator over key-value pairs in descending key order.
+// It is safe to modify the map in the loop body, the iteration goes on from the key preceding the current one.
+func (t *AggregateMap[Key, Value]) Backward() iter.Seq2[Key, Value] {
+	return unwrapAggregates(t.m.Backward())
+}
+
+// AllRange returns an iterator over key-value pairs with keys in the range [from, to] in ascending key order.
+// The bounds are looked up when the iteration starts.
+func (t *AggregateMap[Key, Value]) AllRange(from, to Key) iter.Seq2[Key, Value] {
+	return unwrapAggregates(t.m.AllRange(from, to))
+}
+
+// aggregate combines the values of a subtree in the range [from, to], nil bounds are unbounded.
+// Once the range splits, every side is bounded from one side only,
+// so it descends at most two paths.
+func (t *AggregateMap[Key, Value]) aggregate(x *node[Key, aggregateEntry[Value]], from, to *Key) Value {
+	for x != nil {
+		switch {
+		case from != nil && t.m.keyCompare(x.key, *from):
+			x = x.right
+		case to != nil && t.m.keyCompare(*to, x.key):
+			x = x.left
+		case from == nil && to == nil:
+			return x.value.agg
+		default:
+			left := t.aggregate(x.left, from, nil)
+			right := t.aggregate(x.right, nil, to)
+			return t.monoid.Combine(t.monoid.Combine(left, x.value.value), right)
+		}
+	}
+	return t.monoid.Identity
+}
+
+func aggOf[Key, Value any](
+	x *node[Key, aggregateEntry[Value]],
+	monoid Monoid[Value],
+) Value {
+	if x == nil {
+		return monoid.Identity
+	}
+	return x.value.agg
+}
+
+func unwrapAggregates[Key, Value any](
+	seq iter.Seq2[Key, aggregateEntry[Value]],
+) iter.Seq2[Key, Value] {
+	return func(yield func(Key, Value) bool) {
+		for key, e := range seq {
+			if !yield(key, e.value) {
+				return
+			}
+		}
+	}
+}
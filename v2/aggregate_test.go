@@ -0,0 +1,91 @@
+package treemap
+
+import (
+	"slices"
+	"testing"
+)
+
+var concatMonoid = Monoid[string]{Combine: func(a, b string) string { return a + b }}
+
+func TestAggregate(t *testing.T) {
+	testAggregate(t, NewAggregateMap[int, string](concatMonoid))
+	testAggregate(t, NewAggregateMapWithKeyCompare[int, string](less, concatMonoid))
+}
+
+func TestAggregateSplitJoin(t *testing.T) {
+	tr := NewAggregateMap[int, string](concatMonoid)
+	for i, v := range []string{"a", "b", "c", "d", "e", "f"} {
+		tr.Set(i, v)
+	}
+	left, right := tr.m.Split(3)
+	l := &AggregateMap[int, string]{m: left, monoid: concatMonoid}
+	r := &AggregateMap[int, string]{m: right, monoid: concatMonoid}
+	if l.AggregateAll() != "abc" || r.AggregateAll() != "def" {
+		t.Errorf("wrong aggregates after split, got %s %s", l.AggregateAll(), r.AggregateAll())
+	}
+	r.Set(3, "x")
+	joined := &AggregateMap[int, string]{m: Join(left, right), monoid: concatMonoid}
+	if a := joined.Aggregate(1, 4); a != "bcxe" {
+		t.Errorf("wrong aggregate after join, expected 'bcxe', got '%s'", a)
+	}
+	c := joined.Clone()
+	c.Del(2)
+	if a := c.AggregateAll(); a != "abxef" {
+		t.Errorf("wrong aggregate of a clone, expected 'abxef', got '%s'", a)
+	}
+	c.DelRange(0, 1)
+	if a := c.AggregateAll(); a != "xef" {
+		t.Errorf("wrong aggregate after range deletion, expected 'xef', got '%s'", a)
+	}
+	if !aggregateInvariant(c.m.endNode.left, concatMonoid) || !aggregateInvariant(joined.m.endNode.left, concatMonoid) {
+		t.Error("aggregate invariant error")
+	}
+}
+
+func testAggregate(t *testing.T, tr *AggregateMap[int, string]) {
+	if a := tr.AggregateAll(); a != "" {
+		t.Errorf("wrong aggregate of an empty map, got '%s'", a)
+	}
+	for i, v := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		tr.Set(i*2, v)
+	}
+	tests := []struct {
+		from, to int
+		exp      string
+	}{
+		{0, 14, "abcdefgh"},
+		{-5, 100, "abcdefgh"},
+		{1, 5, "bc"},
+		{2, 4, "bc"},
+		{5, 5, ""},
+		{14, 14, "h"},
+		{10, 2, ""},
+	}
+	for _, test := range tests {
+		if a := tr.Aggregate(test.from, test.to); a != test.exp {
+			t.Errorf("wrong aggregate of [%d, %d], expected '%s', got '%s'", test.from, test.to, test.exp, a)
+		}
+	}
+	tr.Set(4, "x")
+	tr.Del(6)
+	if a := tr.Aggregate(2, 8); a != "bxe" {
+		t.Errorf("wrong aggregate after modification, expected 'bxe', got '%s'", a)
+	}
+	if v, ok := tr.Get(4); !ok || v != "x" || tr.Contains(6) || tr.Len() != 7 {
+		t.Error("wrong contents after modification")
+	}
+	var values []string
+	for _, v := range tr.AllRange(2, 8) {
+		values = append(values, v)
+	}
+	if !slices.Equal(values, []string{"b", "x", "e"}) {
+		t.Errorf("wrong values in range, got %v", values)
+	}
+	if !aggregateInvariant(tr.m.endNode.left, concatMonoid) {
+		t.Error("aggregate invariant error")
+	}
+	tr.Clear()
+	if a := tr.AggregateAll(); a != "" {
+		t.Errorf("wrong aggregate of a cleared map, got '%s'", a)
+	}
+}
@@ -0,0 +1,87 @@
+package treemap
+
+// Union returns a new map containing the keys present in any of the maps.
+// For the keys present in both maps the value is computed by resolve.
+// If resolve is nil then the values of a are kept.
+// Both maps must use the same key compare function, the result uses the one of a.
+// Complexity: O(N + M).
+func Union[Key, Value any](
+	a, b *TreeMap[Key, Value],
+	resolve func(key Key, a, b Value) Value,
+) *TreeMap[Key, Value] {
+	return merge(a, b, true, true, true, resolve)
+}
+
+// Intersection returns a new map containing the keys present in both maps.
+// The values are computed by resolve.
+// If resolve is nil then the values of a are kept.
+// Both maps must use the same key compare function, the result uses the one of a.
+// Complexity: O(N + M).
+func Intersection[Key, Value any](
+	a, b *TreeMap[Key, Value],
+	resolve func(key Key, a, b Value) Value,
+) *TreeMap[Key, Value] {
+	return merge(a, b, false, false, true, resolve)
+}
+
+// Difference returns a new map containing the keys of a that are not present in b.
+// Both maps must use the same key compare function, the result uses the one of a.
+// Complexity: O(N + M).
+func Difference[Key, Value any](a, b *TreeMap[Key, Value]) *TreeMap[Key, Value] {
+	return merge(a, b, true, false, false, nil)
+}
+
+// SymmetricDifference returns a new map containing the keys present in exactly one of the maps.
+// Both maps must use the same key compare function, the result uses the one of a.
+// Complexity: O(N + M).
+func SymmetricDifference[Key, Value any](a, b *TreeMap[Key, Value]) *TreeMap[Key, Value] {
+	return merge(a, b, true, true, false, nil)
+}
+
+// merge walks both maps simultaneously and collects the keys present only in a if keepA is set,
+// the keys present only in b if keepB is set and the common keys if keepBoth is set.
+// The values of the common keys are computed by resolve or taken from a if it is nil.
+func merge[Key, Value any](
+	a, b *TreeMap[Key, Value],
+	keepA, keepB, keepBoth bool,
+	resolve func(key Key, a, b Value) Value,
+) *TreeMap[Key, Value] {
+	var nodes []*node[Key, Value]
+	add := func(key Key, value Value) {
+		nodes = append(nodes, &node[Key, Value]{key: key, value: value})
+	}
+	x, y := a.beginNode, b.beginNode
+	for x != a.endNode && y != b.endNode {
+		switch {
+		case a.keyCompare(x.key, y.key):
+			if keepA {
+				add(x.key, x.value)
+			}
+			x = successor(x)
+		case a.keyCompare(y.key, x.key):
+			if keepB {
+				add(y.key, y.value)
+			}
+			y = successor(y)
+		default:
+			switch {
+			case !keepBoth:
+			case resolve == nil:
+				add(x.key, x.value)
+			default:
+				add(x.key, resolve(x.key, x.value, y.value))
+			}
+			x = successor(x)
+			y = successor(y)
+		}
+	}
+	for ; keepA && x != a.endNode; x = successor(x) {
+		add(x.key, x.value)
+	}
+	for ; keepB && y != b.endNode; y = successor(y) {
+		add(y.key, y.value)
+	}
+	result := a.empty()
+	result.setRoot(result.buildTree(nodes))
+	return result
+}
@@ -0,0 +1,142 @@
+package treemap
+
+import (
+	"errors"
+	"iter"
+	"math/bits"
+
+	"golang.org/x/exp/constraints"
+)
+
+// DuplicatePolicy defines how bulk construction treats equal keys.
+type DuplicatePolicy int
+
+const (
+	// KeepLast keeps the last of equal keys, just like repeated Set calls do
+	KeepLast DuplicatePolicy = iota
+	// KeepFirst keeps the first of equal keys
+	KeepFirst
+	// RejectDuplicates makes bulk construction fail with ErrDuplicateKey
+	RejectDuplicates
+)
+
+// ErrUnsorted is returned by bulk construction if the input is not sorted.
+var ErrUnsorted = errors.New("treemap: input is not sorted")
+
+// ErrDuplicateKey is returned by bulk construction if the input contains equal keys
+// and RejectDuplicates policy is used.
+var ErrDuplicateKey = errors.New("treemap: duplicate key")
+
+// FromSorted creates and returns new TreeMap filled with the keys sorted in ascending order and corresponding values.
+// It returns ErrUnsorted if the keys are not sorted.
+// Equal keys are handled according to the duplicates policy.
+// It panics if keys and values have different lengths.
+// Complexity: O(N).
+func FromSorted[Key constraints.Ordered, Value any](
+	keys []Key,
+	values []Value,
+	duplicates DuplicatePolicy,
+) (*TreeMap[Key, Value], error) {
+	return FromSortedWithKeyCompare(keys, values, duplicates, defaultKeyCompare[Key])
+}
+
+// FromSortedWithKeyCompare creates and returns new TreeMap with the specified key compare function
+// filled with the keys sorted in ascending order and corresponding values.
+// It returns ErrUnsorted if the keys are not sorted.
+// Equal keys are handled according to the duplicates policy.
+// It panics if keys and values have different lengths.
+// Complexity: O(N).
+func FromSortedWithKeyCompare[Key, Value any](
+	keys []Key,
+	values []Value,
+	duplicates DuplicatePolicy,
+	keyCompare func(a, b Key) bool,
+) (*TreeMap[Key, Value], error) {
+	if len(keys) != len(values) {
+		panic("keys and values have different lengths")
+	}
+	seq := func(yield func(Key, Value) bool) {
+		for i := range keys {
+			if !yield(keys[i], values[i]) {
+				return
+			}
+		}
+	}
+	return CollectSortedWithKeyCompare(seq, duplicates, keyCompare)
+}
+
+// CollectSorted creates and returns new TreeMap filled with key-value pairs from seq.
+// The keys must come in ascending order, otherwise it returns ErrUnsorted.
+// Equal keys are handled according to the duplicates policy.
+// Complexity: O(N).
+func CollectSorted[Key constraints.Ordered, Value any](
+	seq iter.Seq2[Key, Value],
+	duplicates DuplicatePolicy,
+) (*TreeMap[Key, Value], error) {
+	return CollectSortedWithKeyCompare(seq, duplicates, defaultKeyCompare[Key])
+}
+
+// CollectSortedWithKeyCompare creates and returns new TreeMap with the specified key compare function
+// filled with key-value pairs from seq.
+// The keys must come in ascending order, otherwise it returns ErrUnsorted.
+// Equal keys are handled according to the duplicates policy.
+// Complexity: O(N).
+func CollectSortedWithKeyCompare[Key, Value any](
+	seq iter.Seq2[Key, Value],
+	duplicates DuplicatePolicy,
+	keyCompare func(a, b Key) bool,
+) (*TreeMap[Key, Value], error) {
+	var nodes []*node[Key, Value]
+	var err error
+	for key, value := range seq {
+		if len(nodes) != 0 {
+			last := nodes[len(nodes)-1]
+			if keyCompare(key, last.key) {
+				err = ErrUnsorted
+				break
+			}
+			if !keyCompare(last.key, key) {
+				if duplicates == RejectDuplicates {
+					err = ErrDuplicateKey
+					break
+				}
+				if duplicates == KeepLast {
+					last.value = value
+				}
+				continue
+			}
+		}
+		nodes = append(nodes, &node[Key, Value]{key: key, value: value})
+	}
+	if err != nil {
+		return nil, err
+	}
+	t := newTreeMap[Key, Value](keyCompare)
+	t.setRoot(t.buildTree(nodes))
+	return t, nil
+}
+
+// buildTree links sorted nodes into a valid red-black tree and returns its root.
+// The tree is perfectly balanced, only the nodes of the deepest level are red.
+// Complexity: O(N).
+func (t *TreeMap[Key, Value]) buildTree(nodes []*node[Key, Value]) *node[Key, Value] {
+	if len(nodes) == 0 {
+		return nil
+	}
+	return t.buildSubtree(nodes, 0, bits.Len(uint(len(nodes)))-1)
+}
+
+func (t *TreeMap[Key, Value]) buildSubtree(
+	nodes []*node[Key, Value],
+	depth int,
+	redDepth int,
+) *node[Key, Value] {
+	if len(nodes) == 0 {
+		return nil
+	}
+	mid := len(nodes) / 2
+	x := nodes[mid]
+	t.link(x, t.buildSubtree(nodes[:mid], depth+1, redDepth), t.buildSubtree(nodes[mid+1:], depth+1, redDepth))
+	x.isBlack = depth != redDepth
+	return x
+}
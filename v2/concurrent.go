@@ -0,0 +1,111 @@
+package treemap
+
+import (
+	"iter"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/exp/constraints"
+)
+
+// ConcurrentMap is the generic goroutine-safe key-sorted map.
+// Readers never block and always see a consistent version of a map, writers are serialized.
+// Every write publishes a new Persistent version atomically.
+type ConcurrentMap[Key, Value any] struct {
+	mu      sync.Mutex
+	current atomic.Pointer[Persistent[Key, Value]]
+}
+
+// NewConcurrent creates and returns new ConcurrentMap.
+func NewConcurrent[Key constraints.Ordered, Value any]() *ConcurrentMap[Key, Value] {
+	return NewConcurrentWithKeyCompare[Key, Value](defaultKeyCompare[Key])
+}
+
+// NewConcurrentWithKeyCompare creates and returns new ConcurrentMap with the specified key compare function.
+// Parameter keyCompare is a function returning a < b.
+func NewConcurrentWithKeyCompare[Key, Value any](
+	keyCompare func(a, b Key) bool,
+) *ConcurrentMap[Key, Value] {
+	c := &ConcurrentMap[Key, Value]{}
+	c.current.Store(NewPersistentWithKeyCompare[Key, Value](keyCompare))
+	return c
+}
+
+// Load returns the current version of a map.
+// It never changes, so use it to make several consistent reads.
+// Complexity: O(1).
+func (c *ConcurrentMap[Key, Value]) Load() *Persistent[Key, Value] { return c.current.Load() }
+
+// Len returns total count of elements in the current version of a map.
+// Complexity: O(1).
+func (c *ConcurrentMap[Key, Value]) Len() int { return c.Load().Len() }
+
+// Get retrieves a value from the current version of a map for specified key and reports if it exists.
+// Complexity: O(log N).
+func (c *ConcurrentMap[Key, Value]) Get(key Key) (Value, bool) { return c.Load().Get(key) }
+
+// Contains checks if key exists in the current version of a map.
+// Complexity: O(log N)
+func (c *ConcurrentMap[Key, Value]) Contains(key Key) bool { return c.Load().Contains(key) }
+
+// Range returns a pair of iterators that you can use to go through all the keys in the range [from, to]
+// of the current version of a map.
+// Loop until the first iterator is Equal to the second one.
+// Complexity: O(log N).
+func (c *ConcurrentMap[Key, Value]) Range(from, to Key) (PersistentIterator[Key, Value], PersistentIterator[Key, Value]) {
+	return c.Load().Range(from, to)
+}
+
+// LowerBound returns an iterator pointing to the first element that is not less than the given key
+// in the current version of a map.
+// Complexity: O(log N).
+func (c *ConcurrentMap[Key, Value]) LowerBound(key Key) PersistentIterator[Key, Value] {
+	return c.Load().LowerBound(key)
+}
+
+// UpperBound returns an iterator pointing to the first element that is greater than the given key
+// in the current version of a map.
+// Complexity: O(log N).
+func (c *ConcurrentMap[Key, Value]) UpperBound(key Key) PersistentIterator[Key, Value] {
+	return c.Load().UpperBound(key)
+}
+
+// Iterator returns an iterator for the current version of a map.
+// Method complexity: O(log N)
+func (c *ConcurrentMap[Key, Value]) Iterator() PersistentIterator[Key, Value] {
+	return c.Load().Iterator()
+}
+
+// Reverse returns a reverse iterator for the current version of a map.
+// Method complexity: O(log N)
+func (c *ConcurrentMap[Key, Value]) Reverse() PersistentReverseIterator[Key, Value] {
+	return c.Load().Reverse()
+}
+
+// All returns an iterator over key-value pairs of the current version of a map in ascending key order.
+func (c *ConcurrentMap[Key, Value]) All() iter.Seq2[Key, Value] { return c.Load().All() }
+
+// Backward returns an iterator over key-value pairs of the current version of a map in descending key order.
+func (c *ConcurrentMap[Key, Value]) Backward() iter.Seq2[Key, Value] { return c.Load().Backward() }
+
+// Set sets the value and silently overrides previous value if it exists.
+// Complexity: O(log N).
+func (c *ConcurrentMap[Key, Value]) Set(key Key, value Value) {
+	c.Update(func(p *Persistent[Key, Value]) *Persistent[Key, Value] { return p.Set(key, value) })
+}
+
+// Del deletes the value.
+// Complexity: O(log N).
+func (c *ConcurrentMap[Key, Value]) Del(key Key) {
+	c.Update(func(p *Persistent[Key, Value]) *Persistent[Key, Value] { return p.Del(key) })
+}
+
+// Update applies a batch of changes to a map and publishes the result once.
+// Function fn gets the current version and returns the new one.
+// Readers see either all the changes or none of them.
+// Writers are blocked while fn is running, so fn must not write to the same map.
+func (c *ConcurrentMap[Key, Value]) Update(fn func(*Persistent[Key, Value]) *Persistent[Key, Value]) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.current.Store(fn(c.current.Load()))
+}
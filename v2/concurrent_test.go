@@ -0,0 +1,98 @@
+package treemap
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestConcurrentMap(t *testing.T) {
+	testConcurrentMap(t, NewConcurrent[int, string]())
+	testConcurrentMap(t, NewConcurrentWithKeyCompare[int, string](less))
+}
+
+func TestConcurrentMapUpdate(t *testing.T) {
+	c := NewConcurrent[int, int]()
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for batch := 0; batch < 50; batch++ {
+				c.Update(func(p *Persistent[int, int]) *Persistent[int, int] {
+					for i := 0; i < 10; i++ {
+						p = p.Set(w*1000+batch*10+i, w)
+					}
+					return p
+				})
+			}
+		}(w)
+	}
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 100; n++ {
+				p := c.Load()
+				count := 0
+				for range p.All() {
+					count++
+				}
+				if count != p.Len() || count%10 != 0 {
+					t.Errorf("inconsistent version with %d elements", count)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if c.Len() != 2000 {
+		t.Errorf("wrong count, expected 2000, got %d", c.Len())
+	}
+}
+
+func testConcurrentMap(t *testing.T, c *ConcurrentMap[int, string]) {
+	for i := 0; i < 10; i++ {
+		c.Set(i, "x")
+	}
+	old := c.Load()
+	c.Del(0)
+	c.Set(1, "y")
+	if v, ok := c.Get(1); !ok || v != "y" {
+		t.Errorf("wrong value, expected 'y', got '%s'", v)
+	}
+	if c.Contains(0) || !old.Contains(0) {
+		t.Error("deletion should be visible only in the new version")
+	}
+	if c.Len() != 9 || old.Len() != 10 {
+		t.Errorf("wrong counts, got %d %d", c.Len(), old.Len())
+	}
+	var keys []int
+	for it, end := c.Range(3, 5); !it.Equal(end); it.Next() {
+		keys = append(keys, it.Key())
+	}
+	if len(keys) != 3 || keys[0] != 3 || keys[2] != 5 {
+		t.Errorf("wrong range, got %v", keys)
+	}
+	if it := c.LowerBound(10); it.Valid() {
+		t.Error("lower bound should not exist")
+	}
+	if it := c.UpperBound(8); !it.Valid() || it.Key() != 9 {
+		t.Error("upper bound should be 9")
+	}
+	if it := c.Iterator(); it.Key() != 1 {
+		t.Errorf("wrong first key, got %d", it.Key())
+	}
+	if it := c.Reverse(); it.Key() != 9 {
+		t.Errorf("wrong last key, got %d", it.Key())
+	}
+	count := 0
+	for range c.All() {
+		count++
+	}
+	for range c.Backward() {
+		count++
+	}
+	if count != 18 {
+		t.Errorf("wrong number of iterated elements, expected 18, got %d", count)
+	}
+}
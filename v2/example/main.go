@@ -13,4 +13,7 @@ func main() {
 	for it := tr.Iterator(); it.Valid(); it.Next() {
 		fmt.Println(it.Key(), it.Value())
 	}
+	for k, v := range tr.Backward() {
+		fmt.Println(k, v)
+	}
 }
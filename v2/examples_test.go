@@ -2,6 +2,7 @@ package treemap
 
 import (
 	"fmt"
+	"maps"
 )
 
 func ExampleTreeMap_Set() {
@@ -98,3 +99,366 @@ func ExampleTreeMap_Range() {
 	// 1 - one
 	// 2 - two
 }
+
+func ExampleTreeMap_Select() {
+	tr := New[int, string]()
+	tr.Set(1, "one")
+	tr.Set(2, "two")
+	tr.Set(3, "three")
+	it := tr.Select(tr.Len() / 2)
+	fmt.Println(it.Key(), "-", it.Value())
+	// Output:
+	// 2 - two
+}
+
+func ExampleTreeMap_Rank() {
+	tr := New[int, string]()
+	tr.Set(10, "ten")
+	tr.Set(20, "twenty")
+	tr.Set(30, "thirty")
+	fmt.Println(tr.Rank(25))
+	// Output:
+	// 2
+}
+
+func ExampleTreeMap_Floor() {
+	tr := New[int, string]()
+	tr.Set(10, "ten")
+	tr.Set(20, "twenty")
+	k, v, ok := tr.Floor(15)
+	fmt.Println(k, v, ok)
+	_, _, ok = tr.Floor(5)
+	fmt.Println(ok)
+	// Output:
+	// 10 ten true
+	// false
+}
+
+func ExampleTreeMap_FloorIterator() {
+	tr := New[int, string]()
+	tr.Set(1, "one")
+	tr.Set(2, "two")
+	tr.Set(3, "three")
+	for it := tr.FloorIterator(2).Reverse(); it.Valid(); it.Next() {
+		fmt.Println(it.Key(), "-", it.Value())
+	}
+	// Output:
+	// 2 - two
+	// 1 - one
+}
+
+func ExampleTreeMap_PopMin() {
+	tr := New[int, string]()
+	tr.Set(2, "two")
+	tr.Set(1, "one")
+	tr.Set(3, "three")
+	for tr.Len() != 0 {
+		k, v, _ := tr.PopMin()
+		fmt.Println(k, "-", v)
+	}
+	// Output:
+	// 1 - one
+	// 2 - two
+	// 3 - three
+}
+
+func ExampleTreeMap_Erase() {
+	tr := New[int, string]()
+	tr.Set(1, "one")
+	tr.Set(2, "two")
+	tr.Set(3, "three")
+	tr.Set(4, "four")
+	for it := tr.Iterator(); it.Valid(); {
+		if it.Key()%2 == 0 {
+			it = tr.Erase(it)
+		} else {
+			it.Next()
+		}
+	}
+	for it := tr.Iterator(); it.Valid(); it.Next() {
+		fmt.Println(it.Key(), "-", it.Value())
+	}
+	// Output:
+	// 1 - one
+	// 3 - three
+}
+
+func ExampleTreeMap_DelRange() {
+	tr := New[int, string]()
+	tr.Set(1, "one")
+	tr.Set(2, "two")
+	tr.Set(3, "three")
+	tr.Set(4, "four")
+	fmt.Println(tr.DelRange(2, 3))
+	for it := tr.Iterator(); it.Valid(); it.Next() {
+		fmt.Println(it.Key(), "-", it.Value())
+	}
+	// Output:
+	// 2
+	// 1 - one
+	// 4 - four
+}
+
+func ExampleTreeMap_Split() {
+	tr := New[int, string]()
+	tr.Set(1, "one")
+	tr.Set(2, "two")
+	tr.Set(3, "three")
+	left, right := tr.Split(2)
+	fmt.Println(left.Len(), right.Len())
+	joined := Join(left, right)
+	fmt.Println(joined.Len())
+	// Output:
+	// 1 2
+	// 3
+}
+
+func ExampleUnion() {
+	a := New[string, int]()
+	a.Set("apples", 1)
+	a.Set("pears", 2)
+	b := New[string, int]()
+	b.Set("pears", 3)
+	b.Set("plums", 4)
+	sum := func(key string, a, b int) int { return a + b }
+	for it := Union(a, b, sum).Iterator(); it.Valid(); it.Next() {
+		fmt.Println(it.Key(), "-", it.Value())
+	}
+	// Output:
+	// apples - 1
+	// pears - 5
+	// plums - 4
+}
+
+func ExampleTreeMap_All() {
+	tr := New[int, string]()
+	tr.Set(1, "one")
+	tr.Set(2, "two")
+	tr.Set(3, "three")
+	for k, v := range tr.All() {
+		fmt.Println(k, "-", v)
+	}
+	// Output:
+	// 1 - one
+	// 2 - two
+	// 3 - three
+}
+
+func ExampleTreeMap_BackwardRange() {
+	tr := New[int, string]()
+	tr.Set(1, "one")
+	tr.Set(2, "two")
+	tr.Set(3, "three")
+	for k, v := range tr.BackwardRange(1, 2) {
+		fmt.Println(k, "-", v)
+	}
+	// Output:
+	// 2 - two
+	// 1 - one
+}
+
+func ExampleCollect() {
+	tr := Collect(maps.All(map[int]string{2: "two", 1: "one"}))
+	for k, v := range tr.All() {
+		fmt.Println(k, "-", v)
+	}
+	// Output:
+	// 1 - one
+	// 2 - two
+}
+
+func ExampleFromSorted() {
+	tr, err := FromSorted([]int{1, 2, 3}, []string{"one", "two", "three"}, RejectDuplicates)
+	if err != nil {
+		panic(err)
+	}
+	for k, v := range tr.All() {
+		fmt.Println(k, "-", v)
+	}
+	// Output:
+	// 1 - one
+	// 2 - two
+	// 3 - three
+}
+
+func ExampleTreeMap_Clone() {
+	tr := New[int, string]()
+	tr.Set(1, "one")
+	c := tr.Clone()
+	c.Set(2, "two")
+	fmt.Println(tr.Len(), c.Len())
+	// Output:
+	// 1 2
+}
+
+func ExamplePersistent_Set() {
+	v1 := NewPersistent[int, string]().Set(1, "one")
+	v2 := v1.Set(2, "two")
+	fmt.Println(v1.Len(), v2.Len())
+	for it := v2.Iterator(); it.Valid(); it.Next() {
+		fmt.Println(it.Key(), "-", it.Value())
+	}
+	// Output:
+	// 1 2
+	// 1 - one
+	// 2 - two
+}
+
+func ExampleTreeMap_Snapshot() {
+	tr := New[int, string]()
+	tr.Set(1, "one")
+	s := tr.Snapshot()
+	tr.Set(2, "two")
+	tr.Del(1)
+	for k, v := range s.All() {
+		fmt.Println(k, "-", v)
+	}
+	// Output:
+	// 1 - one
+}
+
+func ExampleConcurrentMap_Update() {
+	c := NewConcurrent[int, string]()
+	c.Update(func(p *Persistent[int, string]) *Persistent[int, string] {
+		return p.Set(1, "one").Set(2, "two")
+	})
+	for k, v := range c.All() {
+		fmt.Println(k, "-", v)
+	}
+	// Output:
+	// 1 - one
+	// 2 - two
+}
+
+func ExampleSyncTreeMap_GetOrSet() {
+	s := NewSync[string, int]()
+	v, loaded := s.GetOrSet("a", 1)
+	fmt.Println(v, loaded)
+	v, loaded = s.GetOrSet("a", 2)
+	fmt.Println(v, loaded)
+	// Output:
+	// 1 false
+	// 1 true
+}
+
+func ExampleTreeMultiMap_EqualRange() {
+	tr := NewMulti[int, string]()
+	tr.Insert(1, "one")
+	tr.Insert(2, "two")
+	tr.Insert(1, "uno")
+	for it, end := tr.EqualRange(1); it != end; it.Next() {
+		fmt.Println(it.Key(), "-", it.Value())
+	}
+	fmt.Println(tr.Count(1))
+	// Output:
+	// 1 - one
+	// 1 - uno
+	// 2
+}
+
+func ExampleTreeSet_Intersection() {
+	a := NewSet[int]()
+	b := NewSet[int]()
+	for _, k := range []int{1, 2, 3, 4} {
+		a.Add(k)
+	}
+	for _, k := range []int{3, 4, 5} {
+		b.Add(k)
+	}
+	for k := range a.Intersection(b) {
+		fmt.Println(k)
+	}
+	// Output:
+	// 3
+	// 4
+}
+
+func ExampleTreeMultiset_Select() {
+	s := NewMultiset[int]()
+	s.Add(1, 1)
+	s.Add(2, 3)
+	s.Add(5, 1)
+	median, _ := s.Select(s.Len() / 2)
+	fmt.Println(median)
+	// Output:
+	// 2
+}
+
+func ExampleIntervalMap_Stabbing() {
+	m := NewIntervalMap[int, string]()
+	m.Insert(9, 12, "standup")
+	m.Insert(11, 13, "review")
+	m.Insert(14, 15, "lunch")
+	for in, v := range m.Stabbing(11) {
+		fmt.Println(in.Lo, in.Hi, v)
+	}
+	// Output:
+	// 9 12 standup
+	// 11 13 review
+}
+
+func ExampleAggregateMap_Aggregate() {
+	sum := Monoid[int]{Combine: func(a, b int) int { return a + b }}
+	tr := NewAggregateMap[string, int](sum)
+	tr.Set("2024-01", 10)
+	tr.Set("2024-02", 20)
+	tr.Set("2024-03", 30)
+	tr.Set("2024-04", 40)
+	fmt.Println(tr.Aggregate("2024-02", "2024-03"))
+	// Output:
+	// 50
+}
+
+func ExampleTreeMap_CountRange() {
+	tr := New[int, string]()
+	for i := 1; i <= 5; i++ {
+		tr.Set(i, "x")
+	}
+	fmt.Println(tr.CountRange(2, 4, BoundsClosed))
+	fmt.Println(tr.CountRange(2, 4, BoundsOpen))
+	// Output:
+	// 3
+	// 1
+}
+
+func ExampleTreeMap_SetHint() {
+	tr := New[int, string]()
+	hint := tr.End()
+	for i, v := range []string{"a", "b", "c"} {
+		hint = tr.SetHint(hint, i, v)
+		hint.Next()
+	}
+	for k, v := range tr.All() {
+		fmt.Println(k, "-", v)
+	}
+	// Output:
+	// 0 - a
+	// 1 - b
+	// 2 - c
+}
+
+func ExampleTreeMap_Insert() {
+	tr := New[int, string]()
+	_, inserted := tr.Insert(1, "one")
+	fmt.Println(inserted)
+	it, inserted := tr.Insert(1, "uno")
+	fmt.Println(inserted, it.Value())
+	// Output:
+	// true
+	// false uno
+}
+
+func ExampleTreeMap_Update() {
+	tr := New[string, int]()
+	for _, w := range []string{"b", "a", "b"} {
+		tr.Update(w, func(old int, _ bool) (int, bool) { return old + 1, true })
+	}
+	tr.Merge("c", 1, func(old, value int) int { return old + value })
+	for k, v := range tr.All() {
+		fmt.Println(k, "-", v)
+	}
+	// Output:
+	// a - 1
+	// b - 2
+	// c - 1
+}
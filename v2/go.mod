@@ -1,5 +1,5 @@
 module github.com/igrmk/treemap/v2
 
-go 1.18
+go 1.23
 
 require golang.org/x/exp v0.0.0-20220317015231-48e79f11773a
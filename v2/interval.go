@@ -0,0 +1,161 @@
+package treemap
+
+import (
+	"iter"
+
+	"golang.org/x/exp/constraints"
+)
+
+// Interval is a closed interval [Lo, Hi].
+type Interval[Point any] struct {
+	Lo, Hi Point
+}
+
+// IntervalMap is the generic map from closed intervals to values.
+// Intervals are sorted by start, intervals with equal starts are kept in insertion order.
+// Equal intervals are allowed, every value is stored in its own node.
+// Every node stores the greatest end of its subtree,
+// so overlap queries skip the subtrees that end too early.
+type IntervalMap[Point, Value any] struct {
+	m    *TreeMap[Interval[Point], intervalEntry[Point, Value]]
+	less func(a, b Point) bool
+}
+
+type intervalEntry[Point, Value any] struct {
+	value  Value
+	maxEnd Point
+}
+
+// NewIntervalMap creates and returns new IntervalMap.
+func NewIntervalMap[Point constraints.Ordered, Value any]() *IntervalMap[Point, Value] {
+	return NewIntervalMapWithCompare[Point, Value](defaultKeyCompare[Point])
+}
+
+// NewIntervalMapWithCompare creates and returns new IntervalMap with the specified point compare function.
+// Parameter less is a function returning a < b.
+func NewIntervalMapWithCompare[Point, Value any](
+	less func(a, b Point) bool,
+) *IntervalMap[Point, Value] {
+	keyCompare := func(a, b Interval[Point]) bool { return less(a.Lo, b.Lo) }
+	m := NewWithKeyCompare[Interval[Point], intervalEntry[Point, Value]](keyCompare)
+	m.multi = true
+	m.augment = func(x *node[Interval[Point], intervalEntry[Point, Value]]) {
+		end := x.key.Hi
+		if x.left != nil && less(end, x.left.value.maxEnd) {
+			end = x.left.value.maxEnd
+		}
+		if x.right != nil && less(end, x.right.value.maxEnd) {
+			end = x.right.value.maxEnd
+		}
+		x.value.maxEnd = end
+	}
+	return &IntervalMap[Point, Value]{m: m, less: less}
+}
+
+// Len returns total count of intervals in a map.
+// Complexity: O(1).
+func (t *IntervalMap[Point, Value]) Len() int { return t.m.Len() }
+
+// Insert adds the interval [lo, hi] with the value to a map.
+// Existing intervals are never overridden, even equal ones.
+// The new interval goes after all the intervals with an equal start.
+// It panics if hi is less than lo.
+// Complexity: O(log N).
+func (t *IntervalMap[Point, Value]) Insert(lo, hi Point, value Value) {
+	if t.less(hi, lo) {
+		panic("invalid interval")
+	}
+	t.m.startWrite()
+	defer t.m.endWrite()
+	t.m.insertMulti(Interval[Point]{lo, hi}, intervalEntry[Point, Value]{value: value})
+}
+
+// Get retrieves the first value inserted for the interval [lo, hi] and reports if it exists.
+// Complexity: O(log N + D) where D is the number of intervals starting at lo.
+func (t *IntervalMap[Point, Value]) Get(lo, hi Point) (Value, bool) {
+	t.m.reading()
+	for x := t.m.lowerBound(Interval[Point]{lo, hi}); x != t.m.endNode && !t.less(lo, x.key.Lo); x = successor(x) {
+		if t.equal(x.key.Hi, hi) {
+			return x.value.value, true
+		}
+	}
+	var value Value
+	return value, false
+}
+
+// Del deletes all the values inserted for the interval [lo, hi] and returns the number of deleted ones.
+// Complexity: O((D + 1) log N) where D is the number of intervals starting at lo.
+func (t *IntervalMap[Point, Value]) Del(lo, hi Point) int {
+	t.m.startWrite()
+	defer t.m.endWrite()
+	n := 0
+	x := t.m.lowerBound(Interval[Point]{lo, hi})
+	for x != t.m.endNode && !t.less(lo, x.key.Lo) {
+		if !t.equal(x.key.Hi, hi) {
+			x = successor(x)
+			continue
+		}
+		x = t.m.eraseStep(x, false)
+		n++
+	}
+	return n
+}
+
+// Clear clears the map.
+// Complexity: O(1).
+func (t *IntervalMap[Point, Value]) Clear() { t.m.Clear() }
+
+// All returns an iterator over intervals along with their values in ascending order of starts.
+func (t *IntervalMap[Point, Value]) All() iter.Seq2[Interval[Point], Value] {
+	return func(yield func(Interval[Point], Value) bool) {
+		for k, e := range t.m.All() {
+			if !yield(k, e.value) {
+				return
+			}
+		}
+	}
+}
+
+// Overlapping returns an iterator over intervals having common points with [lo, hi]
+// along with their values in ascending order of starts.
+// The map must not be modified during the iteration.
+// Complexity: O((K + 1) log N) where K is the number of yielded intervals.
+func (t *IntervalMap[Point, Value]) Overlapping(lo, hi Point) iter.Seq2[Interval[Point], Value] {
+	return func(yield func(Interval[Point], Value) bool) {
+		t.overlapping(t.m.endNode.left, lo, hi, yield)
+	}
+}
+
+// Stabbing returns an iterator over intervals containing the point along with their values in ascending order of starts.
+// The map must not be modified during the iteration.
+// Complexity: O((K + 1) log N) where K is the number of yielded intervals.
+func (t *IntervalMap[Point, Value]) Stabbing(point Point) iter.Seq2[Interval[Point], Value] {
+	return t.Overlapping(point, point)
+}
+
+func (t *IntervalMap[Point, Value]) equal(a, b Point) bool {
+	return !t.less(a, b) && !t.less(b, a)
+}
+
+// overlapping visits the intervals of a subtree overlapping [lo, hi] in order,
+// it returns false if yield asked to stop
+func (t *IntervalMap[Point, Value]) overlapping(
+	x *node[Interval[Point], intervalEntry[Point, Value]],
+	lo, hi Point,
+	yield func(Interval[Point], Value) bool,
+) bool {
+	if x == nil || t.less(x.value.maxEnd, lo) {
+		return true
+	}
+	t.m.reading()
+	if !t.overlapping(x.left, lo, hi, yield) {
+		return false
+	}
+	if t.less(hi, x.key.Lo) {
+		return true
+	}
+	if !t.less(x.key.Hi, lo) && !yield(x.key, x.value.value) {
+		return false
+	}
+	return t.overlapping(x.right, lo, hi, yield)
+}
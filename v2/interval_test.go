@@ -0,0 +1,93 @@
+package treemap
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestIntervalMap(t *testing.T) {
+	testIntervalMap(t, NewIntervalMap[int, string]())
+	testIntervalMap(t, NewIntervalMapWithCompare[int, string](less))
+}
+
+func TestIntervalMapInvalid(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("invalid interval should panic")
+		}
+	}()
+	NewIntervalMap[int, string]().Insert(2, 1, "x")
+}
+
+func testIntervalMap(t *testing.T, m *IntervalMap[int, string]) {
+	m.Insert(1, 3, "a")
+	m.Insert(2, 8, "b")
+	m.Insert(5, 6, "c")
+	m.Insert(7, 10, "d")
+	m.Insert(1, 2, "e")
+	m.Insert(1, 3, "f")
+	if m.Len() != 6 {
+		t.Errorf("wrong count, expected 6, got %d", m.Len())
+	}
+	if v, ok := m.Get(1, 3); !ok || v != "a" {
+		t.Errorf("wrong value, expected 'a', got '%s'", v)
+	}
+	if v, ok := m.Get(1, 2); !ok || v != "e" {
+		t.Errorf("wrong value, expected 'e', got '%s'", v)
+	}
+	if _, ok := m.Get(1, 4); ok {
+		t.Error("interval should not exist")
+	}
+	values := func(seq func(func(Interval[int], string) bool)) []string {
+		var vs []string
+		for _, v := range seq {
+			vs = append(vs, v)
+		}
+		return vs
+	}
+	tests := []struct {
+		name string
+		vs   []string
+		exp  []string
+	}{
+		{"all", values(m.All()), []string{"a", "e", "f", "b", "c", "d"}},
+		{"stabbing 3", values(m.Stabbing(3)), []string{"a", "f", "b"}},
+		{"stabbing 7", values(m.Stabbing(7)), []string{"b", "d"}},
+		{"stabbing 0", values(m.Stabbing(0)), nil},
+		{"stabbing 11", values(m.Stabbing(11)), nil},
+		{"overlapping 4-5", values(m.Overlapping(4, 5)), []string{"b", "c"}},
+		{"overlapping 0-1", values(m.Overlapping(0, 1)), []string{"a", "e", "f"}},
+		{"overlapping 9-20", values(m.Overlapping(9, 20)), []string{"d"}},
+	}
+	for _, test := range tests {
+		if !slices.Equal(test.vs, test.exp) {
+			t.Errorf("wrong %s, expected %v, got %v", test.name, test.exp, test.vs)
+		}
+	}
+	for range m.Stabbing(2) {
+		break
+	}
+	if m.Del(2, 8) != 1 || m.Del(2, 8) != 0 {
+		t.Error("interval should be deleted once")
+	}
+	s := m.m.Snapshot()
+	if n := m.Del(1, 3); n != 2 {
+		t.Errorf("wrong number of deleted intervals, expected 2, got %d", n)
+	}
+	if vs := values(m.All()); !slices.Equal(vs, []string{"e", "c", "d"}) {
+		t.Errorf("wrong intervals after deleting duplicates, got %v", vs)
+	}
+	if s.Len() != 5 {
+		t.Errorf("wrong snapshot count, expected 5, got %d", s.Len())
+	}
+	if vs := values(m.Stabbing(7)); !slices.Equal(vs, []string{"d"}) {
+		t.Errorf("wrong stabbing after deletion, got %v", vs)
+	}
+	if !intervalInvariant(m.m.endNode.left) {
+		t.Error("interval invariant error")
+	}
+	m.Clear()
+	if m.Len() != 0 {
+		t.Error("map should be empty")
+	}
+}
@@ -0,0 +1,187 @@
+package treemap
+
+import (
+	"iter"
+
+	"golang.org/x/exp/constraints"
+)
+
+// All returns an iterator over key-value pairs in ascending key order.
+// It is safe to modify the map in the loop body, the iteration goes on from the key following the current one.
+func (t *TreeMap[Key, Value]) All() iter.Seq2[Key, Value] {
+	return func(yield func(Key, Value) bool) {
+		t.ascend(t.beginNode, t.endNode, yield)
+	}
+}
+
+// Backward returns an iterator over key-value pairs in descending key order.
+// It is safe to modify the map in the loop body, the iteration goes on from the key preceding the current one.
+func (t *TreeMap[Key, Value]) Backward() iter.Seq2[Key, Value] {
+	return func(yield func(Key, Value) bool) {
+		t.descend(t.lastNode, nil, yield)
+	}
+}
+
+// Keys returns an iterator over keys in ascending order.
+func (t *TreeMap[Key, Value]) Keys() iter.Seq[Key] {
+	return func(yield func(Key) bool) {
+		t.ascend(t.beginNode, t.endNode, func(key Key, _ Value) bool { return yield(key) })
+	}
+}
+
+// Values returns an iterator over values in ascending key order.
+func (t *TreeMap[Key, Value]) Values() iter.Seq[Value] {
+	return func(yield func(Value) bool) {
+		t.ascend(t.beginNode, t.endNode, func(_ Key, value Value) bool { return yield(value) })
+	}
+}
+
+// AllRange returns an iterator over key-value pairs with keys in the range [from, to] in ascending key order.
+// The bounds are looked up when the iteration starts.
+func (t *TreeMap[Key, Value]) AllRange(from, to Key) iter.Seq2[Key, Value] {
+	return func(yield func(Key, Value) bool) {
+		if t.keyCompare(to, from) {
+			return
+		}
+		t.ascend(t.lowerBound(from), t.upperBound(to), yield)
+	}
+}
+
+// BackwardRange returns an iterator over key-value pairs with keys in the range [from, to] in descending key order.
+// The bounds are looked up when the iteration starts.
+func (t *TreeMap[Key, Value]) BackwardRange(from, to Key) iter.Seq2[Key, Value] {
+	return func(yield func(Key, Value) bool) {
+		if t.keyCompare(to, from) {
+			return
+		}
+		t.descend(t.floorNode(to), t.lowerNode(from), yield)
+	}
+}
+
+// AllFrom returns an iterator over key-value pairs with keys not less than the given key in ascending key order.
+func (t *TreeMap[Key, Value]) AllFrom(key Key) iter.Seq2[Key, Value] {
+	return func(yield func(Key, Value) bool) {
+		t.ascend(t.lowerBound(key), t.endNode, yield)
+	}
+}
+
+// BackwardFrom returns an iterator over key-value pairs with keys not greater than the given key in descending key order.
+func (t *TreeMap[Key, Value]) BackwardFrom(key Key) iter.Seq2[Key, Value] {
+	return func(yield func(Key, Value) bool) {
+		t.descend(t.floorNode(key), nil, yield)
+	}
+}
+
+// Collect creates and returns new TreeMap filled with key-value pairs from seq.
+// If a key occurs more than once, the last value wins.
+func Collect[Key constraints.Ordered, Value any](seq iter.Seq2[Key, Value]) *TreeMap[Key, Value] {
+	return CollectWithKeyCompare(seq, defaultKeyCompare[Key])
+}
+
+// CollectWithKeyCompare creates and returns new TreeMap with the specified key compare function
+// filled with key-value pairs from seq.
+// If a key occurs more than once, the last value wins.
+func CollectWithKeyCompare[Key, Value any](
+	seq iter.Seq2[Key, Value],
+	keyCompare func(a, b Key) bool,
+) *TreeMap[Key, Value] {
+	t := NewWithKeyCompare[Key, Value](keyCompare)
+	for key, value := range seq {
+		t.Set(key, value)
+	}
+	return t
+}
+
+// ascend yields the elements in the range [first, last) of positions.
+// If the loop body modifies the map then the next position is found again after yield:
+// it is the successor of the current node if the node is still in place,
+// otherwise the position is looked up by key.
+// Keys are not unique in maps allowing duplicates, so the position among equal keys
+// is remembered and used instead.
+func (t *TreeMap[Key, Value]) ascend(first, last *node[Key, Value], yield func(Key, Value) bool) {
+	for x := first; x != last; {
+		t.reading()
+		writes, mods := t.writes, t.mods
+		pos := -1
+		if t.multi {
+			if next := successor(x); next != t.endNode && !t.keyCompare(x.key, next.key) {
+				pos = t.index(x)
+			}
+		}
+		if !yield(x.key, x.value) {
+			return
+		}
+		if t.writes == writes {
+			x = successor(x)
+			continue
+		}
+		changed := t.mods != mods
+		if last != t.endNode && (changed || last.size == 0) {
+			last = t.lowerBound(last.key)
+		}
+		switch {
+		case !changed && x.size != 0:
+			x = successor(x)
+		case pos >= 0:
+			x = t.nodeAt(x, pos, 1)
+		default:
+			x = t.upperBound(x.key)
+		}
+	}
+}
+
+// descend yields the elements in the range [first, last) of positions in reverse order,
+// nil is the one-before-the-start position.
+// The positions are found again after modifications the same way ascend does.
+func (t *TreeMap[Key, Value]) descend(first, last *node[Key, Value], yield func(Key, Value) bool) {
+	for x := first; x != last; {
+		t.reading()
+		writes, mods := t.writes, t.mods
+		pos := -1
+		if t.multi {
+			if prev := predecessor(x); prev != nil && !t.keyCompare(prev.key, x.key) {
+				pos = t.index(x)
+			}
+		}
+		if !yield(x.key, x.value) {
+			return
+		}
+		if t.writes == writes {
+			x = predecessor(x)
+			continue
+		}
+		changed := t.mods != mods
+		if last != nil && (changed || last.size == 0) {
+			last = t.floorNode(last.key)
+		}
+		switch {
+		case !changed && x.size != 0:
+			x = predecessor(x)
+		case pos >= 0:
+			x = t.nodeAt(x, pos, -1)
+		default:
+			x = t.lowerNode(x.key)
+		}
+	}
+}
+
+// nodeAt returns the neighbour of x in the given direction after a modification of the map,
+// pos is the position x had before the modification.
+// If x is still at that position then its neighbour is returned,
+// if x was deleted then the elements after it have shifted to its position.
+// The one-before-the-start position is nil.
+func (t *TreeMap[Key, Value]) nodeAt(x *node[Key, Value], pos, dir int) *node[Key, Value] {
+	y := t.selectNode(pos)
+	switch {
+	case y == x && dir > 0:
+		return successor(x)
+	case y == x:
+		return predecessor(x)
+	case x.size == 0 && dir > 0:
+		return y
+	}
+	if pos+dir < 0 {
+		return nil
+	}
+	return t.selectNode(pos + dir)
+}
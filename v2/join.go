@@ -0,0 +1,331 @@
+package treemap
+
+// Split moves the keys that are less than the given key to the left map and the rest to the right map.
+// Both maps use the same key compare function as the original one, the original map becomes empty.
+// Iterators of the original map are invalidated.
+// Complexity: O(log N).
+func (t *TreeMap[Key, Value]) Split(key Key) (left, right *TreeMap[Key, Value]) {
+	t.startWrite()
+	defer t.endWrite()
+	root := t.endNode.left
+	l, _, r, _ := t.split(root, blackHeight(root), key, false)
+	left = t.empty()
+	left.epoch = t.epoch
+	left.setRoot(l)
+	right = t.empty()
+	right.epoch = t.epoch
+	right.setRoot(r)
+	t.setRoot(nil)
+	t.mods++
+	return left, right
+}
+
+// Join moves all the elements of left and right to a new map and returns it.
+// All the keys of left must be less than all the keys of right, otherwise Join panics.
+// It panics if left and right are the same map.
+// The new map uses the key compare function of left, both arguments become empty.
+// Iterators of both arguments are invalidated.
+// Complexity: O(log N).
+func Join[Key, Value any](left, right *TreeMap[Key, Value]) *TreeMap[Key, Value] {
+	if left == right {
+		panic("joining a map with itself")
+	}
+	left.startWrite()
+	defer left.endWrite()
+	right.startWrite()
+	defer right.endWrite()
+	if left.lastNode != nil && right.lastNode != nil && !left.keyCompare(left.lastNode.key, right.beginNode.key) {
+		panic("joined maps overlap")
+	}
+	l := left.endNode.left
+	r := right.endNode.left
+	result := left.empty()
+	result.epoch = left.epoch
+	if right.epoch > result.epoch {
+		result.epoch = right.epoch
+	}
+	root, _ := result.join2(l, blackHeight(l), r, blackHeight(r))
+	result.setRoot(root)
+	left.setRoot(nil)
+	left.mods++
+	right.setRoot(nil)
+	right.mods++
+	return result
+}
+
+// The functions below join and split red-black trees by black height.
+// They operate on detached subtrees.
+// A subtree root may be red and its parent link is not maintained, callers attach the result themselves.
+// Nodes shared with snapshots are copied before modification.
+// Black height is the number of black nodes on any path from a subtree root down to a nil leaf.
+
+func isRed[Key, Value any](
+	x *node[Key, Value],
+) bool {
+	return x != nil && !x.isBlack
+}
+
+func blackHeight[Key, Value any](
+	x *node[Key, Value],
+) int {
+	h := 0
+	for ; x != nil; x = x.left {
+		if x.isBlack {
+			h++
+		}
+	}
+	return h
+}
+
+// childBlackHeight returns the black height of the children of x having black height h
+func childBlackHeight[Key, Value any](
+	x *node[Key, Value],
+	h int,
+) int {
+	if x.isBlack {
+		return h - 1
+	}
+	return h
+}
+
+func (t *TreeMap[Key, Value]) link(k, l, r *node[Key, Value]) {
+	k.left = l
+	k.right = r
+	if l != nil {
+		l.parent = k
+	}
+	if r != nil {
+		r.parent = k
+	}
+	t.update(k)
+}
+
+func (t *TreeMap[Key, Value]) rotateLeftSubtree(x *node[Key, Value]) *node[Key, Value] {
+	y := x.right
+	x.right = y.left
+	if x.right != nil {
+		x.right.parent = x
+	}
+	y.left = x
+	x.parent = y
+	t.update(x)
+	t.update(y)
+	return y
+}
+
+func (t *TreeMap[Key, Value]) rotateRightSubtree(x *node[Key, Value]) *node[Key, Value] {
+	y := x.left
+	x.left = y.right
+	if x.left != nil {
+		x.left.parent = x
+	}
+	y.right = x
+	x.parent = y
+	t.update(x)
+	t.update(y)
+	return y
+}
+
+// join returns a tree consisting of l, k and r along with its black height.
+// All the keys of l must be less than k and all the keys of r must be greater than k.
+func (t *TreeMap[Key, Value]) join(
+	l *node[Key, Value],
+	lh int,
+	k *node[Key, Value],
+	r *node[Key, Value],
+	rh int,
+) (*node[Key, Value], int) {
+	if isRed(l) {
+		l = t.mutable(l)
+		l.isBlack = true
+		lh++
+	}
+	if isRed(r) {
+		r = t.mutable(r)
+		r.isBlack = true
+		rh++
+	}
+	k = t.mutable(k)
+	switch {
+	case lh > rh:
+		return t.joinRight(l, lh, k, r, rh), lh
+	case lh < rh:
+		return t.joinLeft(l, lh, k, r, rh), rh
+	default:
+		t.link(k, l, r)
+		k.isBlack = false
+		return k, lh
+	}
+}
+
+// joinRight descends the right spine of l to the black node having the black height of r
+func (t *TreeMap[Key, Value]) joinRight(
+	l *node[Key, Value],
+	lh int,
+	k *node[Key, Value],
+	r *node[Key, Value],
+	rh int,
+) *node[Key, Value] {
+	if !isRed(l) && lh == rh {
+		t.link(k, l, r)
+		k.isBlack = false
+		return k
+	}
+	l = t.mutable(l)
+	c := t.joinRight(l.right, childBlackHeight(l, lh), k, r, rh)
+	l.right = c
+	c.parent = l
+	if !isRed(l) && isRed(c) && isRed(c.right) {
+		c.right = t.mutable(c.right)
+		c.right.parent = c
+		c.right.isBlack = true
+		return t.rotateLeftSubtree(l)
+	}
+	t.update(l)
+	return l
+}
+
+// joinLeft descends the left spine of r to the black node having the black height of l
+func (t *TreeMap[Key, Value]) joinLeft(
+	l *node[Key, Value],
+	lh int,
+	k *node[Key, Value],
+	r *node[Key, Value],
+	rh int,
+) *node[Key, Value] {
+	if !isRed(r) && lh == rh {
+		t.link(k, l, r)
+		k.isBlack = false
+		return k
+	}
+	r = t.mutable(r)
+	c := t.joinLeft(l, lh, k, r.left, childBlackHeight(r, rh))
+	r.left = c
+	c.parent = r
+	if !isRed(r) && isRed(c) && isRed(c.left) {
+		c.left = t.mutable(c.left)
+		c.left.parent = c
+		c.left.isBlack = true
+		return t.rotateRightSubtree(r)
+	}
+	t.update(r)
+	return r
+}
+
+// join2 returns a tree consisting of l and r along with its black height.
+// All the keys of l must be less than all the keys of r.
+func (t *TreeMap[Key, Value]) join2(
+	l *node[Key, Value],
+	lh int,
+	r *node[Key, Value],
+	rh int,
+) (*node[Key, Value], int) {
+	if r == nil {
+		return l, lh
+	}
+	if l == nil {
+		return r, rh
+	}
+	r, m := t.removeMin(r)
+	return t.join(l, lh, m, r, blackHeight(r))
+}
+
+// removeMin removes the least node from a subtree and returns the new subtree root along with the removed node
+func (t *TreeMap[Key, Value]) removeMin(
+	x *node[Key, Value],
+) (*node[Key, Value], *node[Key, Value]) {
+	x = t.mutable(x)
+	sentinel := &node[Key, Value]{isBlack: true, left: x}
+	x.parent = sentinel
+	x.isBlack = true
+	m := t.own(mostLeft(x))
+	t.removeNode(x, m)
+	return sentinel.left, m
+}
+
+// split splits a subtree of black height h into the keys going before the given key and the rest.
+// If inclusive is set then the given key itself goes to the left part.
+func (t *TreeMap[Key, Value]) split(
+	x *node[Key, Value],
+	h int,
+	key Key,
+	inclusive bool,
+) (l *node[Key, Value], lh int, r *node[Key, Value], rh int) {
+	if x == nil {
+		return nil, 0, nil, 0
+	}
+	ch := childBlackHeight(x, h)
+	left, right := x.left, x.right
+	if t.keyCompare(x.key, key) || inclusive && !t.keyCompare(key, x.key) {
+		l1, l1h, r1, r1h := t.split(right, ch, key, inclusive)
+		l, lh = t.join(left, ch, x, l1, l1h)
+		return l, lh, r1, r1h
+	}
+	l1, l1h, r1, r1h := t.split(left, ch, key, inclusive)
+	r, rh = t.join(r1, r1h, x, right, ch)
+	return l1, l1h, r, rh
+}
+
+// splitAt splits a subtree of black height h into its first n nodes and the rest
+func (t *TreeMap[Key, Value]) splitAt(
+	x *node[Key, Value],
+	h int,
+	n int,
+) (l *node[Key, Value], lh int, r *node[Key, Value], rh int) {
+	if x == nil {
+		return nil, 0, nil, 0
+	}
+	ch := childBlackHeight(x, h)
+	left, right := x.left, x.right
+	if ls := sizeOf(left); ls < n {
+		l1, l1h, r1, r1h := t.splitAt(right, ch, n-ls-1)
+		l, lh = t.join(left, ch, x, l1, l1h)
+		return l, lh, r1, r1h
+	}
+	l1, l1h, r1, r1h := t.splitAt(left, ch, n)
+	r, rh = t.join(r1, r1h, x, right, ch)
+	return l1, l1h, r, rh
+}
+
+// setRoot makes x the root of a tree and recalculates the cached fields
+func (t *TreeMap[Key, Value]) setRoot(x *node[Key, Value]) {
+	if x == nil {
+		t.endNode.left = nil
+		t.count = 0
+		t.beginNode = t.endNode
+		t.lastNode = nil
+		return
+	}
+	x = t.mutable(x)
+	t.endNode.left = x
+	x.parent = t.endNode
+	x.isBlack = true
+	t.count = x.size
+	t.beginNode = mostLeft(x)
+	t.lastNode = mostRight(x)
+}
+
+// eraseRange deletes the elements in the range [first, last) of positions
+// and returns the number of deleted elements.
+// It splits the tree by position rather than by key, so it works with duplicate keys too.
+func (t *TreeMap[Key, Value]) eraseRange(first, last *node[Key, Value]) int {
+	if first == last || first == t.endNode {
+		return 0
+	}
+	i, j := t.index(first), t.index(last)
+	if j <= i {
+		return 0
+	}
+	root := t.endNode.left
+	h := blackHeight(root)
+	var right *node[Key, Value]
+	var rh int
+	if last != t.endNode {
+		root, h, right, rh = t.splitAt(root, h, j)
+	}
+	left, lh, mid, _ := t.splitAt(root, h, i)
+	root, _ = t.join2(left, lh, right, rh)
+	t.setRoot(root)
+	t.mods++
+	return sizeOf(mid)
+}
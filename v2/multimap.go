@@ -0,0 +1,154 @@
+package treemap
+
+import (
+	"iter"
+
+	"golang.org/x/exp/constraints"
+)
+
+// TreeMultiMap is the generic key-sorted map allowing duplicate keys.
+// Every value is stored in its own node.
+// Values with equal keys are kept in insertion order.
+type TreeMultiMap[Key, Value any] struct {
+	m *TreeMap[Key, Value]
+}
+
+// NewMulti creates and returns new TreeMultiMap.
+func NewMulti[Key constraints.Ordered, Value any]() *TreeMultiMap[Key, Value] {
+	return NewMultiWithKeyCompare[Key, Value](defaultKeyCompare[Key])
+}
+
+// NewMultiWithKeyCompare creates and returns new TreeMultiMap with the specified key compare function.
+// Parameter keyCompare is a function returning a < b.
+func NewMultiWithKeyCompare[Key, Value any](
+	keyCompare func(a, b Key) bool,
+) *TreeMultiMap[Key, Value] {
+	m := NewWithKeyCompare[Key, Value](keyCompare)
+	m.multi = true
+	return &TreeMultiMap[Key, Value]{m: m}
+}
+
+// Len returns total count of elements in a map.
+// Complexity: O(1).
+func (t *TreeMultiMap[Key, Value]) Len() int { return t.m.Len() }
+
+// Insert adds a key-value pair to a map and returns an iterator pointing to it.
+// The new element goes after all the elements with an equal key.
+// Complexity: O(log N).
+func (t *TreeMultiMap[Key, Value]) Insert(key Key, value Value) ForwardIterator[Key, Value] {
+	t.m.startWrite()
+	defer t.m.endWrite()
+	return t.m.iterator(t.m.insertMulti(key, value))
+}
+
+// Get retrieves the first value inserted for specified key and reports if it exists.
+// Complexity: O(log N).
+func (t *TreeMultiMap[Key, Value]) Get(key Key) (Value, bool) {
+	it := t.m.LowerBound(key)
+	if !it.Valid() || t.m.keyCompare(key, it.Key()) {
+		var value Value
+		return value, false
+	}
+	return it.Value(), true
+}
+
+// Contains checks if key exists in a map.
+// Complexity: O(log N)
+func (t *TreeMultiMap[Key, Value]) Contains(key Key) bool {
+	_, ok := t.Get(key)
+	return ok
+}
+
+// Count returns the number of elements with the given key.
+// Complexity: O(log N).
+func (t *TreeMultiMap[Key, Value]) Count(key Key) int {
+	first, last := t.EqualRange(key)
+	return t.m.index(last.node) - t.m.index(first.node)
+}
+
+// EqualRange returns a pair of iterators that you can use to go through all the elements with the given key.
+// More specifically it returns iterators pointing to lower bound and upper bound.
+// Complexity: O(log N).
+func (t *TreeMultiMap[Key, Value]) EqualRange(key Key) (ForwardIterator[Key, Value], ForwardIterator[Key, Value]) {
+	return t.m.Range(key, key)
+}
+
+// Range returns a pair of iterators that you can use to go through all the keys in the range [from, to].
+// More specifically it returns iterators pointing to lower bound and upper bound.
+// Complexity: O(log N).
+func (t *TreeMultiMap[Key, Value]) Range(from, to Key) (ForwardIterator[Key, Value], ForwardIterator[Key, Value]) {
+	return t.m.Range(from, to)
+}
+
+// LowerBound returns an iterator pointing to the first element that is not less than the given key.
+// Complexity: O(log N).
+func (t *TreeMultiMap[Key, Value]) LowerBound(key Key) ForwardIterator[Key, Value] {
+	return t.m.LowerBound(key)
+}
+
+// UpperBound returns an iterator pointing to the first element that is greater than the given key.
+// Complexity: O(log N).
+func (t *TreeMultiMap[Key, Value]) UpperBound(key Key) ForwardIterator[Key, Value] {
+	return t.m.UpperBound(key)
+}
+
+// DelAll deletes all the elements with the given key and returns the number of deleted elements.
+// Complexity: O(log N).
+func (t *TreeMultiMap[Key, Value]) DelAll(key Key) int {
+	return t.m.DelRange(key, key)
+}
+
+// Erase deletes the element at the iterator position and returns an iterator pointing to the next element.
+// Only the iterators pointing to the deleted element are invalidated.
+// It panics if the iterator is at the one-past-the-end position.
+// Complexity: O(log N) with no key comparisons.
+func (t *TreeMultiMap[Key, Value]) Erase(it ForwardIterator[Key, Value]) ForwardIterator[Key, Value] {
+	return t.m.Erase(it)
+}
+
+// EraseRange deletes the elements in the range [first, last) and returns the number of deleted elements.
+// The range is taken by position, so it may start or end in the middle of equal keys.
+// If any elements are deleted then all the iterators of the map are invalidated
+// except the ones at the one-past-the-end position.
+// Complexity: O(log N).
+func (t *TreeMultiMap[Key, Value]) EraseRange(first, last ForwardIterator[Key, Value]) int {
+	return t.m.EraseRange(first, last)
+}
+
+// Clear clears the map.
+// Complexity: O(1).
+func (t *TreeMultiMap[Key, Value]) Clear() { t.m.Clear() }
+
+// Iterator returns an iterator for a map.
+// It starts at the first element and goes to the one-past-the-end position.
+// Method complexity: O(1)
+func (t *TreeMultiMap[Key, Value]) Iterator() ForwardIterator[Key, Value] { return t.m.Iterator() }
+
+// End returns an iterator pointing to the one-past-the-end position.
+// Complexity: O(1)
+func (t *TreeMultiMap[Key, Value]) End() ForwardIterator[Key, Value] { return t.m.End() }
+
+// Reverse returns a reverse iterator for a map.
+// It starts at the last element and goes to the one-before-the-start position.
+// Method complexity: O(1)
+func (t *TreeMultiMap[Key, Value]) Reverse() ReverseIterator[Key, Value] { return t.m.Reverse() }
+
+// All returns an iterator over key-value pairs in ascending key order.
+// Values with equal keys come in insertion order.
+func (t *TreeMultiMap[Key, Value]) All() iter.Seq2[Key, Value] { return t.m.All() }
+
+// Backward returns an iterator over key-value pairs in descending key order.
+// Values with equal keys come in reverse insertion order.
+func (t *TreeMultiMap[Key, Value]) Backward() iter.Seq2[Key, Value] { return t.m.Backward() }
+
+// Keys returns an iterator over keys in ascending order.
+// Duplicate keys are yielded as many times as they occur.
+func (t *TreeMultiMap[Key, Value]) Keys() iter.Seq[Key] { return t.m.Keys() }
+
+// Values returns an iterator over values in ascending key order.
+func (t *TreeMultiMap[Key, Value]) Values() iter.Seq[Value] { return t.m.Values() }
+
+// AllRange returns an iterator over key-value pairs in the range [from, to] in ascending key order.
+func (t *TreeMultiMap[Key, Value]) AllRange(from, to Key) iter.Seq2[Key, Value] {
+	return t.m.AllRange(from, to)
+}
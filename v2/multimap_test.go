@@ -0,0 +1,157 @@
+package treemap
+
+import "testing"
+
+func TestMultiMap(t *testing.T) {
+	testMultiMap(t, NewMulti[int, string]())
+	testMultiMap(t, NewMultiWithKeyCompare[int, string](less))
+}
+
+func TestMultiMapEraseRange(t *testing.T) {
+	testMultiMapEraseRange(t, NewMulti[int, string]())
+	testMultiMapEraseRange(t, NewMultiWithKeyCompare[int, string](less))
+}
+
+func TestMultiMapModifyWhileRanging(t *testing.T) {
+	testMultiMapModifyWhileRanging(t, NewMulti[int, string]())
+	testMultiMapModifyWhileRanging(t, NewMultiWithKeyCompare[int, string](less))
+}
+
+func testMultiMap(t *testing.T, tr *TreeMultiMap[int, string]) {
+	tr.Insert(1, "a")
+	tr.Insert(2, "b")
+	tr.Insert(1, "c")
+	it := tr.Insert(1, "d")
+	if it.Key() != 1 || it.Value() != "d" {
+		t.Errorf("wrong inserted element, got %d %s", it.Key(), it.Value())
+	}
+	if tr.Len() != 4 {
+		t.Errorf("wrong count, expected 4, got %d", tr.Len())
+	}
+	if v, ok := tr.Get(1); !ok || v != "a" {
+		t.Errorf("wrong first value, expected 'a', got '%s'", v)
+	}
+	if _, ok := tr.Get(3); ok || tr.Contains(3) || !tr.Contains(2) {
+		t.Error("wrong contains")
+	}
+	var values []string
+	for first, last := tr.EqualRange(1); first != last; first.Next() {
+		values = append(values, first.Value())
+	}
+	if len(values) != 3 || values[0] != "a" || values[1] != "c" || values[2] != "d" {
+		t.Errorf("wrong values, expected [a c d], got %v", values)
+	}
+	if tr.Count(1) != 3 || tr.Count(2) != 1 || tr.Count(0) != 0 || tr.Count(3) != 0 {
+		t.Error("wrong counts")
+	}
+	first, _ := tr.EqualRange(1)
+	if next := tr.Erase(first); next.Value() != "c" {
+		t.Errorf("wrong next value after erasing, got %s", next.Value())
+	}
+	if n := tr.DelAll(1); n != 2 {
+		t.Errorf("wrong number of deleted elements, expected 2, got %d", n)
+	}
+	if tr.Len() != 1 || tr.Iterator().Key() != 2 {
+		t.Error("only 2 should be left")
+	}
+	tr.Clear()
+	if tr.Len() != 0 || tr.Iterator() != tr.End() {
+		t.Error("map should be empty")
+	}
+}
+
+func testMultiMapEraseRange(t *testing.T, tr *TreeMultiMap[int, string]) {
+	values := func() string {
+		var s string
+		for _, v := range tr.All() {
+			s += v
+		}
+		return s
+	}
+	fill := func() {
+		tr.Clear()
+		tr.Insert(1, "a")
+		tr.Insert(1, "b")
+		tr.Insert(1, "c")
+		tr.Insert(2, "d")
+	}
+	at := func(i int) ForwardIterator[int, string] {
+		it := tr.Iterator()
+		for ; i > 0; i-- {
+			it.Next()
+		}
+		return it
+	}
+	fill()
+	if n := tr.EraseRange(at(1), at(2)); n != 1 || values() != "acd" {
+		t.Errorf("wrong erasing of [b, c), deleted %d, left %s", n, values())
+	}
+	fill()
+	if n := tr.EraseRange(at(1), tr.End()); n != 3 || values() != "a" {
+		t.Errorf("wrong erasing of [b, end), deleted %d, left %s", n, values())
+	}
+	fill()
+	if n := tr.EraseRange(at(2), at(1)); n != 0 || values() != "abcd" {
+		t.Errorf("wrong erasing of an empty range, deleted %d, left %s", n, values())
+	}
+	fill()
+	if n := tr.EraseRange(at(0), at(3)); n != 3 || values() != "d" {
+		t.Errorf("wrong erasing of [a, d), deleted %d, left %s", n, values())
+	}
+}
+
+func testMultiMapModifyWhileRanging(t *testing.T, tr *TreeMultiMap[int, string]) {
+	fill := func() {
+		tr.Clear()
+		tr.Insert(1, "a")
+		tr.Insert(1, "b")
+		tr.Insert(1, "c")
+		tr.Insert(2, "d")
+		tr.Insert(3, "e")
+	}
+	tests := []struct {
+		name string
+		seq  func(yield func(int, string) bool)
+		at   string
+		do   func()
+		exp  string
+	}{
+		{"deleting a key after", tr.All(), "a", func() { tr.DelAll(3) }, "abcd"},
+		{"deleting a key before", tr.Backward(), "c", func() { tr.DelAll(3) }, "edcba"},
+		{"erasing the current element", tr.All(), "a", func() { tr.Erase(tr.Iterator()) }, "abcde"},
+		{"erasing the current element backward", tr.Backward(), "b", func() {
+			first, _ := tr.EqualRange(1)
+			first.Next()
+			tr.Erase(first)
+		}, "edcba"},
+		{"erasing the current element after a snapshot", tr.All(), "a", func() {
+			tr.m.Snapshot()
+			tr.Erase(tr.Iterator())
+		}, "abcde"},
+		{"erasing the next element after a snapshot", tr.All(), "a", func() {
+			tr.m.Snapshot()
+			it := tr.Iterator()
+			it.Next()
+			tr.Erase(it)
+		}, "acde"},
+		{"erasing a range", tr.Backward(), "c", func() {
+			first, _ := tr.EqualRange(1)
+			first.Next()
+			first.Next()
+			tr.EraseRange(first, tr.End())
+		}, "edcba"},
+	}
+	for _, test := range tests {
+		fill()
+		var values string
+		for _, v := range test.seq {
+			values += v
+			if v == test.at {
+				test.do()
+			}
+		}
+		if values != test.exp {
+			t.Errorf("wrong values when %s, expected %s, got %s", test.name, test.exp, values)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package treemap
+
+import (
+	"iter"
+
+	"golang.org/x/exp/constraints"
+)
+
+// TreeMultiset is the generic key-sorted multiset.
+// It keeps a count per distinct key.
+// Every node stores the total count of its subtree,
+// so order statistics take multiplicities into account.
+type TreeMultiset[Key any] struct {
+	m *TreeMap[Key, multisetEntry]
+}
+
+type multisetEntry struct {
+	count  int
+	weight int
+}
+
+// NewMultiset creates and returns new TreeMultiset.
+func NewMultiset[Key constraints.Ordered]() *TreeMultiset[Key] {
+	return NewMultisetWithKeyCompare[Key](defaultKeyCompare[Key])
+}
+
+// NewMultisetWithKeyCompare creates and returns new TreeMultiset with the specified key compare function.
+// Parameter keyCompare is a function returning a < b.
+func NewMultisetWithKeyCompare[Key any](
+	keyCompare func(a, b Key) bool,
+) *TreeMultiset[Key] {
+	m := NewWithKeyCompare[Key, multisetEntry](keyCompare)
+	m.augment = updateWeight[Key]
+	return &TreeMultiset[Key]{m: m}
+}
+
+// Len returns total count of elements in a multiset counting multiplicities.
+// Complexity: O(1).
+func (s *TreeMultiset[Key]) Len() int { return weightOf(s.m.endNode.left) }
+
+// Distinct returns the number of distinct keys in a multiset.
+// Complexity: O(1).
+func (s *TreeMultiset[Key]) Distinct() int { return s.m.Len() }
+
+// Add adds n occurrences of the key to a multiset.
+// It panics if n is negative.
+// Complexity: O(log N).
+func (s *TreeMultiset[Key]) Add(key Key, n int) {
+	if n < 0 {
+		panic("negative count")
+	}
+	if n == 0 {
+		return
+	}
+	s.m.startWrite()
+	defer s.m.endWrite()
+	x, parent, less := s.m.locate(key)
+	if x == nil {
+		s.m.insertNode(parent, less, key, multisetEntry{count: n})
+		return
+	}
+	x = s.m.own(x)
+	x.value.count += n
+	s.m.updatePath(x)
+}
+
+// Remove removes up to n occurrences of the key from a multiset and returns the number of removed ones.
+// The key is deleted when its count drops to zero.
+// It panics if n is negative.
+// Complexity: O(log N).
+func (s *TreeMultiset[Key]) Remove(key Key, n int) int {
+	if n < 0 {
+		panic("negative count")
+	}
+	s.m.startWrite()
+	defer s.m.endWrite()
+	x := s.m.findNode(key)
+	if x == nil || n == 0 {
+		return 0
+	}
+	if x.value.count <= n {
+		n = x.value.count
+		s.m.erase(x)
+		return n
+	}
+	x = s.m.own(x)
+	x.value.count -= n
+	s.m.updatePath(x)
+	return n
+}
+
+// Count returns the number of occurrences of the key.
+// Complexity: O(log N).
+func (s *TreeMultiset[Key]) Count(key Key) int {
+	s.m.reading()
+	x := s.m.findNode(key)
+	if x == nil {
+		return 0
+	}
+	return x.value.count
+}
+
+// Has checks if the key exists in a multiset.
+// Complexity: O(log N).
+func (s *TreeMultiset[Key]) Has(key Key) bool { return s.m.Contains(key) }
+
+// Clear clears the multiset.
+// Complexity: O(1).
+func (s *TreeMultiset[Key]) Clear() { s.m.Clear() }
+
+// Min returns the least key along with its count.
+// It reports if the multiset is not empty.
+// Complexity: O(1).
+func (s *TreeMultiset[Key]) Min() (Key, int, bool) { return multisetEntryOf(s.m.Min()) }
+
+// Max returns the greatest key along with its count.
+// It reports if the multiset is not empty.
+// Complexity: O(1).
+func (s *TreeMultiset[Key]) Max() (Key, int, bool) { return multisetEntryOf(s.m.Max()) }
+
+// Select returns the k-th smallest element counting multiplicities and counting from zero.
+// It reports if k is in range.
+// Complexity: O(log N).
+func (s *TreeMultiset[Key]) Select(k int) (Key, bool) {
+	s.m.reading()
+	x := s.m.endNode.left
+	if k < 0 || k >= weightOf(x) {
+		var key Key
+		return key, false
+	}
+	for {
+		left := weightOf(x.left)
+		switch {
+		case k < left:
+			x = x.left
+		case k >= left+x.value.count:
+			k -= left + x.value.count
+			x = x.right
+		default:
+			return x.key, true
+		}
+	}
+}
+
+// Rank returns the number of elements that are less than the given key counting multiplicities.
+// Complexity: O(log N).
+func (s *TreeMultiset[Key]) Rank(key Key) int {
+	s.m.reading()
+	rank := 0
+	x := s.m.endNode.left
+	for x != nil {
+		if s.m.keyCompare(x.key, key) {
+			rank += weightOf(x.left) + x.value.count
+			x = x.right
+		} else {
+			x = x.left
+		}
+	}
+	return rank
+}
+
+// All returns an iterator over distinct keys along with their counts in ascending key order.
+func (s *TreeMultiset[Key]) All() iter.Seq2[Key, int] {
+	return func(yield func(Key, int) bool) {
+		for k, e := range s.m.All() {
+			if !yield(k, e.count) {
+				return
+			}
+		}
+	}
+}
+
+// Backward returns an iterator over distinct keys along with their counts in descending key order.
+func (s *TreeMultiset[Key]) Backward() iter.Seq2[Key, int] {
+	return func(yield func(Key, int) bool) {
+		for k, e := range s.m.Backward() {
+			if !yield(k, e.count) {
+				return
+			}
+		}
+	}
+}
+
+// Elements returns an iterator over elements in ascending order.
+// Every key is yielded as many times as it occurs.
+func (s *TreeMultiset[Key]) Elements() iter.Seq[Key] {
+	return func(yield func(Key) bool) {
+		for k, e := range s.m.All() {
+			for i := 0; i < e.count; i++ {
+				if !yield(k) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func updateWeight[Key any](
+	x *node[Key, multisetEntry],
+) {
+	x.value.weight = x.value.count + weightOf(x.left) + weightOf(x.right)
+}
+
+func weightOf[Key any](
+	x *node[Key, multisetEntry],
+) int {
+	if x == nil {
+		return 0
+	}
+	return x.value.weight
+}
+
+func multisetEntryOf[Key any](
+	key Key,
+	e multisetEntry,
+	ok bool,
+) (Key, int, bool) {
+	return key, e.count, ok
+}
@@ -0,0 +1,89 @@
+package treemap
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMultiset(t *testing.T) {
+	testMultiset(t, NewMultiset[int]())
+	testMultiset(t, NewMultisetWithKeyCompare[int](less))
+}
+
+func TestMultisetNegativeCount(t *testing.T) {
+	s := NewMultiset[int]()
+	for _, f := range []func(){func() { s.Add(1, -1) }, func() { s.Remove(1, -1) }} {
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Error("negative count should panic")
+				}
+			}()
+			f()
+		}()
+	}
+}
+
+func testMultiset(t *testing.T, s *TreeMultiset[int]) {
+	s.Add(5, 2)
+	s.Add(1, 1)
+	s.Add(3, 3)
+	s.Add(5, 1)
+	s.Add(7, 0)
+	if s.Len() != 7 || s.Distinct() != 3 {
+		t.Errorf("wrong sizes, got %d %d", s.Len(), s.Distinct())
+	}
+	if s.Count(5) != 3 || s.Count(3) != 3 || s.Count(7) != 0 || s.Has(7) {
+		t.Error("wrong counts")
+	}
+	var elements []int
+	for k := 0; k < s.Len(); k++ {
+		key, _ := s.Select(k)
+		elements = append(elements, key)
+	}
+	exp := []int{1, 3, 3, 3, 5, 5, 5}
+	if !slices.Equal(elements, exp) {
+		t.Errorf("wrong selected elements, expected %v, got %v", exp, elements)
+	}
+	if actual := slices.Collect(s.Elements()); !slices.Equal(actual, exp) {
+		t.Errorf("wrong elements, expected %v, got %v", exp, actual)
+	}
+	if _, ok := s.Select(7); ok {
+		t.Error("select should be out of range")
+	}
+	if s.Rank(3) != 1 || s.Rank(4) != 4 || s.Rank(10) != 7 {
+		t.Error("wrong ranks")
+	}
+	if n := s.Remove(3, 2); n != 2 || s.Count(3) != 1 {
+		t.Errorf("wrong removal, removed %d", n)
+	}
+	if n := s.Remove(5, 10); n != 3 || s.Has(5) {
+		t.Errorf("wrong removal, removed %d", n)
+	}
+	if n := s.Remove(8, 1); n != 0 {
+		t.Errorf("wrong removal, removed %d", n)
+	}
+	if k, n, ok := s.Min(); !ok || k != 1 || n != 1 {
+		t.Errorf("wrong min, got %d %d", k, n)
+	}
+	if k, n, ok := s.Max(); !ok || k != 3 || n != 1 {
+		t.Errorf("wrong max, got %d %d", k, n)
+	}
+	var counts []int
+	for k, n := range s.All() {
+		counts = append(counts, k, n)
+	}
+	for k, n := range s.Backward() {
+		counts = append(counts, k, n)
+	}
+	if exp := []int{1, 1, 3, 1, 3, 1, 1, 1}; !slices.Equal(counts, exp) {
+		t.Errorf("wrong counts, expected %v, got %v", exp, counts)
+	}
+	if !multisetInvariant(s.m.endNode.left) {
+		t.Error("multiset invariant error")
+	}
+	s.Clear()
+	if s.Len() != 0 {
+		t.Error("multiset should be empty")
+	}
+}
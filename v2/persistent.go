@@ -0,0 +1,547 @@
+package treemap
+
+import (
+	"iter"
+
+	"golang.org/x/exp/constraints"
+)
+
+// Persistent is the generic immutable red-black tree based map.
+// Set and Del return new maps sharing all untouched subtrees with the original one,
+// so every version stays valid and can be used concurrently.
+// Nodes have no parent links, iterators keep a path from the root instead.
+type Persistent[Key, Value any] struct {
+	root       *pnode[Key, Value]
+	count      int
+	keyCompare func(a Key, b Key) bool
+}
+
+type pnode[Key, Value any] struct {
+	right   *pnode[Key, Value]
+	left    *pnode[Key, Value]
+	isBlack bool
+	key     Key
+	value   Value
+}
+
+// NewPersistent creates and returns new empty Persistent map.
+func NewPersistent[Key constraints.Ordered, Value any]() *Persistent[Key, Value] {
+	return &Persistent[Key, Value]{keyCompare: defaultKeyCompare[Key]}
+}
+
+// NewPersistentWithKeyCompare creates and returns new empty Persistent map with the specified key compare function.
+// Parameter keyCompare is a function returning a < b.
+func NewPersistentWithKeyCompare[Key, Value any](
+	keyCompare func(a, b Key) bool,
+) *Persistent[Key, Value] {
+	return &Persistent[Key, Value]{keyCompare: keyCompare}
+}
+
+// Len returns total count of elements in a map.
+// Complexity: O(1).
+func (p *Persistent[Key, Value]) Len() int { return p.count }
+
+// Set returns a map with the value set for the specified key.
+// The original map is not changed.
+// Complexity: O(log N).
+func (p *Persistent[Key, Value]) Set(key Key, value Value) *Persistent[Key, Value] {
+	root, added := p.insert(p.root, key, value)
+	count := p.count
+	if added {
+		count++
+	}
+	return &Persistent[Key, Value]{root: paint(root, true), count: count, keyCompare: p.keyCompare}
+}
+
+// Del returns a map without the specified key.
+// The original map is not changed.
+// If the key does not exist then the original map is returned.
+// Complexity: O(log N).
+func (p *Persistent[Key, Value]) Del(key Key) *Persistent[Key, Value] {
+	if p.findNode(key) == nil {
+		return p
+	}
+	root := paint(p.del(p.root, key), true)
+	return &Persistent[Key, Value]{root: root, count: p.count - 1, keyCompare: p.keyCompare}
+}
+
+// Get retrieves a value from a map for specified key and reports if it exists.
+// Complexity: O(log N).
+func (p *Persistent[Key, Value]) Get(key Key) (Value, bool) {
+	x := p.findNode(key)
+	if x == nil {
+		var value Value
+		return value, false
+	}
+	return x.value, true
+}
+
+// Contains checks if key exists in a map.
+// Complexity: O(log N)
+func (p *Persistent[Key, Value]) Contains(key Key) bool { return p.findNode(key) != nil }
+
+// Range returns a pair of iterators that you can use to go through all the keys in the range [from, to].
+// More specifically it returns iterators pointing to lower bound and upper bound.
+// Loop until the first iterator is Equal to the second one.
+// Complexity: O(log N).
+func (p *Persistent[Key, Value]) Range(from, to Key) (PersistentIterator[Key, Value], PersistentIterator[Key, Value]) {
+	return p.LowerBound(from), p.UpperBound(to)
+}
+
+// LowerBound returns an iterator pointing to the first element that is not less than the given key.
+// Complexity: O(log N).
+func (p *Persistent[Key, Value]) LowerBound(key Key) PersistentIterator[Key, Value] {
+	path := bound(p.root, func(x *pnode[Key, Value]) bool { return !p.keyCompare(x.key, key) })
+	return PersistentIterator[Key, Value]{root: p.root, path: path}
+}
+
+// UpperBound returns an iterator pointing to the first element that is greater than the given key.
+// Complexity: O(log N).
+func (p *Persistent[Key, Value]) UpperBound(key Key) PersistentIterator[Key, Value] {
+	path := bound(p.root, func(x *pnode[Key, Value]) bool { return p.keyCompare(key, x.key) })
+	return PersistentIterator[Key, Value]{root: p.root, path: path}
+}
+
+// Iterator returns an iterator for a map.
+// It starts at the first element and goes to the one-past-the-end position.
+// You can iterate a map at O(N) complexity.
+// Method complexity: O(log N)
+func (p *Persistent[Key, Value]) Iterator() PersistentIterator[Key, Value] {
+	return PersistentIterator[Key, Value]{root: p.root, path: pushLeft(nil, p.root)}
+}
+
+// Reverse returns a reverse iterator for a map.
+// It starts at the last element and goes to the one-before-the-start position.
+// You can iterate a map at O(N) complexity.
+// Method complexity: O(log N)
+func (p *Persistent[Key, Value]) Reverse() PersistentReverseIterator[Key, Value] {
+	return PersistentReverseIterator[Key, Value]{root: p.root, path: pushRight(nil, p.root)}
+}
+
+// All returns an iterator over key-value pairs in ascending key order.
+func (p *Persistent[Key, Value]) All() iter.Seq2[Key, Value] {
+	return func(yield func(Key, Value) bool) {
+		ascendP(p.root, yield)
+	}
+}
+
+// Backward returns an iterator over key-value pairs in descending key order.
+func (p *Persistent[Key, Value]) Backward() iter.Seq2[Key, Value] {
+	return func(yield func(Key, Value) bool) {
+		descendP(p.root, yield)
+	}
+}
+
+func (p *Persistent[Key, Value]) findNode(key Key) *pnode[Key, Value] {
+	current := p.root
+	for current != nil {
+		switch {
+		case p.keyCompare(key, current.key):
+			current = current.left
+		case p.keyCompare(current.key, key):
+			current = current.right
+		default:
+			return current
+		}
+	}
+	return nil
+}
+
+// The algorithms below follow Kahrs, "Red-black trees with types".
+// Nodes are never modified, every change creates new nodes instead.
+
+func newPNode[Key, Value any](
+	isBlack bool,
+	left *pnode[Key, Value],
+	key Key,
+	value Value,
+	right *pnode[Key, Value],
+) *pnode[Key, Value] {
+	return &pnode[Key, Value]{left: left, right: right, isBlack: isBlack, key: key, value: value}
+}
+
+func paint[Key, Value any](
+	x *pnode[Key, Value],
+	isBlack bool,
+) *pnode[Key, Value] {
+	if x == nil || x.isBlack == isBlack {
+		return x
+	}
+	return newPNode(isBlack, x.left, x.key, x.value, x.right)
+}
+
+func isRedP[Key, Value any](
+	x *pnode[Key, Value],
+) bool {
+	return x != nil && !x.isBlack
+}
+
+func isBlackP[Key, Value any](
+	x *pnode[Key, Value],
+) bool {
+	return x != nil && x.isBlack
+}
+
+func (p *Persistent[Key, Value]) insert(
+	x *pnode[Key, Value],
+	key Key,
+	value Value,
+) (*pnode[Key, Value], bool) {
+	if x == nil {
+		return newPNode(false, nil, key, value, nil), true
+	}
+	switch {
+	case p.keyCompare(key, x.key):
+		left, added := p.insert(x.left, key, value)
+		if x.isBlack {
+			return balance(left, x.key, x.value, x.right), added
+		}
+		return newPNode(false, left, x.key, x.value, x.right), added
+	case p.keyCompare(x.key, key):
+		right, added := p.insert(x.right, key, value)
+		if x.isBlack {
+			return balance(x.left, x.key, x.value, right), added
+		}
+		return newPNode(false, x.left, x.key, x.value, right), added
+	default:
+		return newPNode(x.isBlack, x.left, x.key, value, x.right), false
+	}
+}
+
+// del removes an existing key from a subtree
+func (p *Persistent[Key, Value]) del(
+	x *pnode[Key, Value],
+	key Key,
+) *pnode[Key, Value] {
+	switch {
+	case p.keyCompare(key, x.key):
+		if isBlackP(x.left) {
+			return balanceLeft(p.del(x.left, key), x.key, x.value, x.right)
+		}
+		return newPNode(false, p.del(x.left, key), x.key, x.value, x.right)
+	case p.keyCompare(x.key, key):
+		if isBlackP(x.right) {
+			return balanceRight(x.left, x.key, x.value, p.del(x.right, key))
+		}
+		return newPNode(false, x.left, x.key, x.value, p.del(x.right, key))
+	default:
+		return combine(x.left, x.right)
+	}
+}
+
+// balance resolves a red-red violation below a black node
+func balance[Key, Value any](
+	a *pnode[Key, Value],
+	key Key,
+	value Value,
+	b *pnode[Key, Value],
+) *pnode[Key, Value] {
+	switch {
+	case isRedP(a) && isRedP(b):
+		return newPNode(false, paint(a, true), key, value, paint(b, true))
+	case isRedP(a) && isRedP(a.left):
+		return newPNode(false,
+			paint(a.left, true),
+			a.key, a.value,
+			newPNode(true, a.right, key, value, b))
+	case isRedP(a) && isRedP(a.right):
+		return newPNode(false,
+			newPNode(true, a.left, a.key, a.value, a.right.left),
+			a.right.key, a.right.value,
+			newPNode(true, a.right.right, key, value, b))
+	case isRedP(b) && isRedP(b.right):
+		return newPNode(false,
+			newPNode(true, a, key, value, b.left),
+			b.key, b.value,
+			paint(b.right, true))
+	case isRedP(b) && isRedP(b.left):
+		return newPNode(false,
+			newPNode(true, a, key, value, b.left.left),
+			b.left.key, b.left.value,
+			newPNode(true, b.left.right, b.key, b.value, b.right))
+	default:
+		return newPNode(true, a, key, value, b)
+	}
+}
+
+// balanceLeft restores the invariant when the black height of the left subtree has decreased by one
+func balanceLeft[Key, Value any](
+	left *pnode[Key, Value],
+	key Key,
+	value Value,
+	right *pnode[Key, Value],
+) *pnode[Key, Value] {
+	switch {
+	case isRedP(left):
+		return newPNode(false, paint(left, true), key, value, right)
+	case isBlackP(right):
+		return balance(left, key, value, paint(right, false))
+	default:
+		return newPNode(false,
+			newPNode(true, left, key, value, right.left.left),
+			right.left.key, right.left.value,
+			balance(right.left.right, right.key, right.value, paint(right.right, false)))
+	}
+}
+
+// balanceRight restores the invariant when the black height of the right subtree has decreased by one
+func balanceRight[Key, Value any](
+	left *pnode[Key, Value],
+	key Key,
+	value Value,
+	right *pnode[Key, Value],
+) *pnode[Key, Value] {
+	switch {
+	case isRedP(right):
+		return newPNode(false, left, key, value, paint(right, true))
+	case isBlackP(left):
+		return balance(paint(left, false), key, value, right)
+	default:
+		return newPNode(false,
+			balance(paint(left.left, false), left.key, left.value, left.right.left),
+			left.right.key, left.right.value,
+			newPNode(true, left.right.right, key, value, right))
+	}
+}
+
+// combine joins two subtrees of the same black height left after removing their parent
+func combine[Key, Value any](
+	a, b *pnode[Key, Value],
+) *pnode[Key, Value] {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	case isRedP(a) && isRedP(b):
+		bc := combine(a.right, b.left)
+		if isRedP(bc) {
+			return newPNode(false,
+				newPNode(false, a.left, a.key, a.value, bc.left),
+				bc.key, bc.value,
+				newPNode(false, bc.right, b.key, b.value, b.right))
+		}
+		return newPNode(false, a.left, a.key, a.value, newPNode(false, bc, b.key, b.value, b.right))
+	case a.isBlack && b.isBlack:
+		bc := combine(a.right, b.left)
+		if isRedP(bc) {
+			return newPNode(false,
+				newPNode(true, a.left, a.key, a.value, bc.left),
+				bc.key, bc.value,
+				newPNode(true, bc.right, b.key, b.value, b.right))
+		}
+		return balanceLeft(a.left, a.key, a.value, newPNode(true, bc, b.key, b.value, b.right))
+	case isRedP(b):
+		return newPNode(false, combine(a, b.left), b.key, b.value, b.right)
+	default:
+		return newPNode(false, a.left, a.key, a.value, combine(a.right, b))
+	}
+}
+
+// ascendP yields the elements of a subtree in ascending order and reports if the iteration should go on
+func ascendP[Key, Value any](
+	x *pnode[Key, Value],
+	yield func(Key, Value) bool,
+) bool {
+	for ; x != nil; x = x.right {
+		if !ascendP(x.left, yield) || !yield(x.key, x.value) {
+			return false
+		}
+	}
+	return true
+}
+
+// descendP yields the elements of a subtree in descending order and reports if the iteration should go on
+func descendP[Key, Value any](
+	x *pnode[Key, Value],
+	yield func(Key, Value) bool,
+) bool {
+	for ; x != nil; x = x.left {
+		if !descendP(x.right, yield) || !yield(x.key, x.value) {
+			return false
+		}
+	}
+	return true
+}
+
+// ppath is a path from the root to the current node stored as an immutable linked list from the current node up.
+// Moving along the path creates new entries and never changes the existing ones,
+// so copies of an iterator are independent. A nil path is empty.
+type ppath[Key, Value any] struct {
+	node   *pnode[Key, Value]
+	parent *ppath[Key, Value]
+}
+
+func pushLeft[Key, Value any](
+	p *ppath[Key, Value],
+	x *pnode[Key, Value],
+) *ppath[Key, Value] {
+	for ; x != nil; x = x.left {
+		p = &ppath[Key, Value]{node: x, parent: p}
+	}
+	return p
+}
+
+func pushRight[Key, Value any](
+	p *ppath[Key, Value],
+	x *pnode[Key, Value],
+) *ppath[Key, Value] {
+	for ; x != nil; x = x.right {
+		p = &ppath[Key, Value]{node: x, parent: p}
+	}
+	return p
+}
+
+// bound returns a path to the first node satisfying the predicate.
+// The predicate must be false for a prefix of the nodes in order and true for the rest.
+// If there is no such node the path is empty.
+func bound[Key, Value any](
+	root *pnode[Key, Value],
+	pred func(*pnode[Key, Value]) bool,
+) *ppath[Key, Value] {
+	var p, result *ppath[Key, Value]
+	for x := root; x != nil; {
+		p = &ppath[Key, Value]{node: x, parent: p}
+		if pred(x) {
+			result = p
+			x = x.left
+		} else {
+			x = x.right
+		}
+	}
+	return result
+}
+
+// next returns a path to the successor, the path is empty after the last node
+func (p *ppath[Key, Value]) next() *ppath[Key, Value] {
+	if p.node.right != nil {
+		return pushLeft(p, p.node.right)
+	}
+	for {
+		x := p.node
+		p = p.parent
+		if p == nil || p.node.left == x {
+			return p
+		}
+	}
+}
+
+// prev returns a path to the predecessor, the path is empty before the first node
+func (p *ppath[Key, Value]) prev() *ppath[Key, Value] {
+	if p.node.left != nil {
+		return pushRight(p, p.node.left)
+	}
+	for {
+		x := p.node
+		p = p.parent
+		if p == nil || p.node.right == x {
+			return p
+		}
+	}
+}
+
+// samePosition reports if two paths point to the same node
+func samePosition[Key, Value any](
+	a, b *ppath[Key, Value],
+) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.node == b.node
+}
+
+// PersistentIterator represents a position in a persistent map.
+// It is designed to iterate a map in a forward order.
+// It can point to any position from the first element to the one-past-the-end element.
+// Iterators stay valid forever since the map they belong to never changes.
+// Iterators are small and can be copied, use Equal to compare them.
+type PersistentIterator[Key, Value any] struct {
+	_    [0]func() // iterators are compared with Equal
+	root *pnode[Key, Value]
+	path *ppath[Key, Value]
+}
+
+// Valid reports if the iterator position is valid.
+// In other words it returns true if an iterator is not at the one-past-the-end position.
+func (i PersistentIterator[Key, Value]) Valid() bool { return i.path != nil }
+
+// Equal reports if two iterators of the same map point to the same position.
+func (i PersistentIterator[Key, Value]) Equal(other PersistentIterator[Key, Value]) bool {
+	return i.root == other.root && samePosition(i.path, other.path)
+}
+
+// Next moves an iterator to the next element.
+// It panics if it goes out of bounds.
+func (i *PersistentIterator[Key, Value]) Next() {
+	if i.path == nil {
+		panic("out of bound iteration")
+	}
+	i.path = i.path.next()
+}
+
+// Prev moves an iterator to the previous element.
+// It panics if it goes out of bounds.
+func (i *PersistentIterator[Key, Value]) Prev() {
+	if i.path == nil {
+		i.path = pushRight(nil, i.root)
+	} else {
+		i.path = i.path.prev()
+	}
+	if i.path == nil {
+		panic("out of bound iteration")
+	}
+}
+
+// Key returns a key at the iterator position
+func (i PersistentIterator[Key, Value]) Key() Key { return i.path.node.key }
+
+// Value returns a value at the iterator position
+func (i PersistentIterator[Key, Value]) Value() Value { return i.path.node.value }
+
+// PersistentReverseIterator represents a position in a persistent map.
+// It is designed to iterate a map in a reverse order.
+// It can point to any position from the one-before-the-start element to the last element.
+// Iterators stay valid forever since the map they belong to never changes.
+// Iterators are small and can be copied, use Equal to compare them.
+type PersistentReverseIterator[Key, Value any] struct {
+	_    [0]func() // iterators are compared with Equal
+	root *pnode[Key, Value]
+	path *ppath[Key, Value]
+}
+
+// Valid reports if the iterator position is valid.
+// In other words it returns true if an iterator is not at the one-before-the-start position.
+func (i PersistentReverseIterator[Key, Value]) Valid() bool { return i.path != nil }
+
+// Equal reports if two iterators of the same map point to the same position.
+func (i PersistentReverseIterator[Key, Value]) Equal(other PersistentReverseIterator[Key, Value]) bool {
+	return i.root == other.root && samePosition(i.path, other.path)
+}
+
+// Next moves an iterator to the next element in reverse order.
+// It panics if it goes out of bounds.
+func (i *PersistentReverseIterator[Key, Value]) Next() {
+	if i.path == nil {
+		panic("out of bound iteration")
+	}
+	i.path = i.path.prev()
+}
+
+// Prev moves an iterator to the previous element in reverse order.
+// It panics if it goes out of bounds.
+func (i *PersistentReverseIterator[Key, Value]) Prev() {
+	if i.path == nil {
+		i.path = pushLeft(nil, i.root)
+	} else {
+		i.path = i.path.next()
+	}
+	if i.path == nil {
+		panic("out of bound iteration")
+	}
+}
+
+// Key returns a key at the iterator position
+func (i PersistentReverseIterator[Key, Value]) Key() Key { return i.path.node.key }
+
+// Value returns a value at the iterator position
+func (i PersistentReverseIterator[Key, Value]) Value() Value { return i.path.node.value }
@@ -0,0 +1,251 @@
+package treemap
+
+import (
+	"math/rand"
+	"reflect"
+	"slices"
+	"sort"
+	"testing"
+	"unsafe"
+)
+
+func TestPersistentSetGet(t *testing.T) {
+	testPersistentSetGet(t, NewPersistent[int, string]())
+	testPersistentSetGet(t, NewPersistentWithKeyCompare[int, string](less))
+}
+
+func TestPersistentDel(t *testing.T) {
+	testPersistentDel(t, NewPersistent[int, string]())
+	testPersistentDel(t, NewPersistentWithKeyCompare[int, string](less))
+}
+
+func TestPersistentBounds(t *testing.T) {
+	testPersistentBounds(t, NewPersistent[int, string]())
+	testPersistentBounds(t, NewPersistentWithKeyCompare[int, string](less))
+}
+
+func TestPersistentIteration(t *testing.T) {
+	testPersistentIteration(t, NewPersistent[int, string]())
+	testPersistentIteration(t, NewPersistentWithKeyCompare[int, string](less))
+}
+
+func TestPersistentIteratorCopies(t *testing.T) {
+	p := NewPersistent[int, string]()
+	for i := 0; i < 100; i++ {
+		p = p.Set(i, "x")
+	}
+	it := p.LowerBound(40)
+	c := it
+	for i := 0; i < 30; i++ {
+		c.Next()
+	}
+	c.Prev()
+	if it.Key() != 40 || c.Key() != 69 {
+		t.Errorf("copies should be independent, got %d and %d", it.Key(), c.Key())
+	}
+	for !c.Equal(it) {
+		c.Prev()
+	}
+	if !c.Equal(p.LowerBound(40)) || c.Equal(p.UpperBound(40)) {
+		t.Error("wrong iterator equality")
+	}
+	if size := unsafe.Sizeof(it); size > 2*unsafe.Sizeof(uintptr(0)) {
+		t.Errorf("iterator is too large, got %d bytes", size)
+	}
+}
+
+func TestPersistentOutOfBounds(t *testing.T) {
+	p := NewPersistent[int, string]().Set(0, "a")
+	for name, move := range map[string]func(){
+		"forward next":  func() { it := p.Iterator(); it.Next(); it.Next() },
+		"forward prev":  func() { it := p.Iterator(); it.Prev() },
+		"reverse next":  func() { it := p.Reverse(); it.Next(); it.Next() },
+		"reverse prev":  func() { it := p.Reverse(); it.Prev() },
+		"empty forward": func() { it := NewPersistent[int, string]().Iterator(); it.Prev() },
+	} {
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("%s should have panicked!", name)
+				}
+			}()
+			move()
+		}()
+	}
+}
+
+func TestPersistentRandom(t *testing.T) {
+	p := NewPersistent[int, string]()
+	mp := make(map[int]string)
+	type version struct {
+		p  *Persistent[int, string]
+		mp map[int]string
+	}
+	var versions []version
+	for i, kv := range testRandomData() {
+		if i%3 == 0 && (i/200)%2 == 0 {
+			p = p.Set(kv.k, kv.v)
+			mp[kv.k] = kv.v
+		} else {
+			p = p.Del(kv.k)
+			delete(mp, kv.k)
+		}
+		if !persistentInvariant(p.root) {
+			t.Errorf("invariant error")
+			return
+		}
+		if i%100 == 0 {
+			snapshot := make(map[int]string, len(mp))
+			for k, v := range mp {
+				snapshot[k] = v
+			}
+			versions = append(versions, version{p, snapshot})
+		}
+	}
+	for _, v := range versions {
+		testPersistentContent(t, v.mp, v.p)
+	}
+}
+
+func testPersistentContent(t *testing.T, mp map[int]string, p *Persistent[int, string]) {
+	if p.Len() != len(mp) {
+		t.Errorf("wrong count, expected %d, actual %d", len(mp), p.Len())
+	}
+	var expKeys []int
+	for k := range mp {
+		expKeys = append(expKeys, k)
+	}
+	sort.Ints(expKeys)
+	var actualKeys []int
+	for k, v := range p.All() {
+		actualKeys = append(actualKeys, k)
+		if mp[k] != v {
+			t.Errorf("wrong value, expected %s, actual %s", mp[k], v)
+		}
+	}
+	if !reflect.DeepEqual(actualKeys, expKeys) {
+		t.Errorf("wrong keys, expected %v, actual %v", expKeys, actualKeys)
+	}
+	actualKeys = actualKeys[:0]
+	for k := range p.Backward() {
+		actualKeys = append(actualKeys, k)
+	}
+	slices.Reverse(actualKeys)
+	if len(expKeys) != 0 && !reflect.DeepEqual(actualKeys, expKeys) {
+		t.Errorf("wrong reverse keys, expected %v, actual %v", expKeys, actualKeys)
+	}
+}
+
+func testPersistentSetGet(t *testing.T, p *Persistent[int, string]) {
+	p1 := p.Set(0, "x")
+	p2 := p1.Set(0, "y").Set(1, "z")
+	if p.Len() != 0 || p1.Len() != 1 || p2.Len() != 2 {
+		t.Errorf("wrong counts, got %d %d %d", p.Len(), p1.Len(), p2.Len())
+	}
+	if v, ok := p1.Get(0); !ok || v != "x" {
+		t.Errorf("wrong value in the old version, expected 'x', got '%s'", v)
+	}
+	if v, ok := p2.Get(0); !ok || v != "y" {
+		t.Errorf("wrong value in the new version, expected 'y', got '%s'", v)
+	}
+	if p1.Contains(1) || !p2.Contains(1) {
+		t.Error("wrong presence of a key")
+	}
+	if _, ok := p.Get(0); ok {
+		t.Error("empty map should not contain anything")
+	}
+}
+
+func testPersistentDel(t *testing.T, p *Persistent[int, string]) {
+	for i := 0; i < 100; i++ {
+		p = p.Set(i, "x")
+	}
+	if p.Del(100) != p {
+		t.Error("deleting an absent key should return the same map")
+	}
+	q := p
+	for i := 0; i < 100; i += 2 {
+		q = q.Del(i)
+		if !persistentInvariant(q.root) {
+			t.Error("invariant error")
+		}
+	}
+	if p.Len() != 100 || q.Len() != 50 {
+		t.Errorf("wrong counts, got %d %d", p.Len(), q.Len())
+	}
+	for i := 0; i < 100; i++ {
+		if !p.Contains(i) {
+			t.Errorf("old version should contain %d", i)
+		}
+		if q.Contains(i) != (i%2 != 0) {
+			t.Errorf("wrong presence of %d in the new version", i)
+		}
+	}
+}
+
+func testPersistentBounds(t *testing.T, p *Persistent[int, string]) {
+	if p.LowerBound(0).Valid() || p.UpperBound(0).Valid() {
+		t.Error("bounds should not exist in an empty map")
+	}
+	for i := 1; i <= 10; i++ {
+		p = p.Set(i*2, "x")
+	}
+	for _, tb := range [][3]int{{0, 2, 2}, {2, 2, 4}, {3, 4, 4}, {19, 20, 20}} {
+		if it := p.LowerBound(tb[0]); !it.Valid() || it.Key() != tb[1] {
+			t.Errorf("lower bound of %d should be %d", tb[0], tb[1])
+		}
+		if it := p.UpperBound(tb[0]); !it.Valid() || it.Key() != tb[2] {
+			t.Errorf("upper bound of %d should be %d", tb[0], tb[2])
+		}
+	}
+	if p.LowerBound(21).Valid() || p.UpperBound(20).Valid() {
+		t.Error("bounds should not exist")
+	}
+	var keys []int
+	for it, end := p.Range(5, 11); !it.Equal(end); it.Next() {
+		keys = append(keys, it.Key())
+	}
+	if !slices.Equal(keys, []int{6, 8, 10}) {
+		t.Errorf("wrong range, got %v", keys)
+	}
+	if it, end := p.Range(30, 40); !it.Equal(end) {
+		t.Error("range should be empty")
+	}
+}
+
+func testPersistentIteration(t *testing.T, p *Persistent[int, string]) {
+	keys := rand.Perm(50)
+	for _, k := range keys {
+		p = p.Set(k, "x")
+	}
+	count := 0
+	fwd := p.Iterator()
+	for ; fwd.Valid(); fwd.Next() {
+		if fwd.Key() != count {
+			t.Errorf("wrong key, expected %d, got %d", count, fwd.Key())
+		}
+		count++
+	}
+	for !fwd.Equal(p.Iterator()) {
+		fwd.Prev()
+		count--
+		if fwd.Key() != count {
+			t.Errorf("wrong key, expected %d, got %d", count, fwd.Key())
+		}
+	}
+	rev := p.Reverse()
+	count = 50
+	for ; rev.Valid(); rev.Next() {
+		count--
+		if rev.Key() != count {
+			t.Errorf("wrong key, expected %d, got %d", count, rev.Key())
+		}
+	}
+	for !rev.Equal(p.Reverse()) {
+		rev.Prev()
+		if rev.Key() != count {
+			t.Errorf("wrong key, expected %d, got %d", count, rev.Key())
+		}
+		count++
+	}
+}
@@ -3,6 +3,7 @@ package treemap
 import (
 	"math/rand"
 	"reflect"
+	"slices"
 	"sort"
 	"strconv"
 	"testing"
@@ -38,6 +39,7 @@ func TestRandom(t *testing.T) {
 		testKeys(t, mp, tr)
 		testMinMax(t, mp, tr)
 		testReverse(t, mp, tr)
+		testOrderStatistics(t, mp, tr)
 
 		if !treeInvariant(tr.endNode.left) {
 			t.Errorf("invariant error")
@@ -45,6 +47,457 @@ func TestRandom(t *testing.T) {
 	}
 }
 
+func TestRandomSwapTake(t *testing.T) {
+	tr := New[int, string]()
+	mp := make(map[int]string)
+	for i, kv := range testRandomData() {
+		k, v := kv.k, kv.v
+		exp, expOK := mp[k]
+		if i%3 == 0 && (i/200)%2 == 0 {
+			if old, existed := tr.Swap(k, v); old != exp || existed != expOK {
+				t.Errorf("wrong swapped value, expected %s, actual %s", exp, old)
+			}
+			mp[k] = v
+		} else {
+			if actual, actualOK := tr.Take(k); actual != exp || actualOK != expOK {
+				t.Errorf("wrong taken value, expected %s, actual %s", exp, actual)
+			}
+			delete(mp, k)
+		}
+		if len(mp) != tr.Len() {
+			t.Fatalf("wrong count, expected %d, actual %d", len(mp), tr.Len())
+		}
+		testKeys(t, mp, tr)
+		if !treeInvariant(tr.endNode.left) {
+			t.Fatal("invariant error")
+		}
+	}
+}
+
+func TestRandomPopMinMax(t *testing.T) {
+	tr := New[int, string]()
+	mp := make(map[int]string)
+	for i, kv := range testRandomData() {
+		switch {
+		case i%5 == 0:
+			exp := min(mp)
+			k, v, ok := tr.PopMin()
+			if ok != (exp != nil) || ok && (k != *exp || v != mp[k]) {
+				t.Fatalf("wrong popped minimum, expected %v, actual %d %s %v", exp, k, v, ok)
+			}
+			delete(mp, k)
+		case i%7 == 0:
+			exp := max(mp)
+			k, v, ok := tr.PopMax()
+			if ok != (exp != nil) || ok && (k != *exp || v != mp[k]) {
+				t.Fatalf("wrong popped maximum, expected %v, actual %d %s %v", exp, k, v, ok)
+			}
+			delete(mp, k)
+		default:
+			tr.Set(kv.k, kv.v)
+			mp[kv.k] = kv.v
+		}
+		if len(mp) != tr.Len() {
+			t.Fatalf("wrong count, expected %d, actual %d", len(mp), tr.Len())
+		}
+		testMinMax(t, mp, tr)
+		if !treeInvariant(tr.endNode.left) {
+			t.Fatal("invariant error")
+		}
+	}
+}
+
+func TestRandomDelRange(t *testing.T) {
+	for i := 0; i < 200; i++ {
+		tr := New[int, string]()
+		mp := make(map[int]string)
+		for _, kv := range testRandomData()[:rand.Intn(100)] {
+			tr.Set(kv.k, kv.v)
+			mp[kv.k] = kv.v
+		}
+		from := int(rand.Int63n(RandMax))
+		to := int(rand.Int63n(RandMax))
+		exp := 0
+		for k := range mp {
+			if k >= from && k <= to {
+				delete(mp, k)
+				exp++
+			}
+		}
+		if n := tr.DelRange(from, to); n != exp {
+			t.Errorf("wrong number of deleted elements, expected %d, actual %d", exp, n)
+		}
+		if len(mp) != tr.Len() {
+			t.Errorf("wrong count, expected %d, actual %d", len(mp), tr.Len())
+			return
+		}
+		testKeys(t, mp, tr)
+		testMinMax(t, mp, tr)
+		testReverse(t, mp, tr)
+		testOrderStatistics(t, mp, tr)
+		if !treeInvariant(tr.endNode.left) {
+			t.Errorf("invariant error")
+		}
+	}
+}
+
+func TestRandomSplitJoin(t *testing.T) {
+	for i := 0; i < 200; i++ {
+		tr := New[int, string]()
+		mp := make(map[int]string)
+		for _, kv := range testRandomData()[:rand.Intn(100)] {
+			tr.Set(kv.k, kv.v)
+			mp[kv.k] = kv.v
+		}
+		key := int(rand.Int63n(RandMax))
+		left, right := tr.Split(key)
+		lmp := make(map[int]string)
+		rmp := make(map[int]string)
+		for k, v := range mp {
+			if k < key {
+				lmp[k] = v
+			} else {
+				rmp[k] = v
+			}
+		}
+		for _, part := range []struct {
+			mp map[int]string
+			tr *TreeMap[int, string]
+		}{{lmp, left}, {rmp, right}} {
+			testKeys(t, part.mp, part.tr)
+			testMinMax(t, part.mp, part.tr)
+			testOrderStatistics(t, part.mp, part.tr)
+			if !treeInvariant(part.tr.endNode.left) {
+				t.Errorf("invariant error")
+			}
+		}
+		tr = Join(left, right)
+		testKeys(t, mp, tr)
+		testMinMax(t, mp, tr)
+		testReverse(t, mp, tr)
+		testOrderStatistics(t, mp, tr)
+		if !treeInvariant(tr.endNode.left) {
+			t.Errorf("invariant error")
+		}
+	}
+}
+
+func TestRandomUnion(t *testing.T) {
+	for i := 0; i < 200; i++ {
+		a := New[int, string]()
+		b := New[int, string]()
+		mp := make(map[int]string)
+		for _, kv := range testRandomData()[:rand.Intn(100)] {
+			a.Set(kv.k, kv.v)
+			mp[kv.k] = kv.v
+		}
+		for _, kv := range testRandomData()[:rand.Intn(100)] {
+			b.Set(kv.k, kv.v)
+		}
+		for it := b.Iterator(); it.Valid(); it.Next() {
+			mp[it.Key()] += it.Value()
+		}
+		u := Union(a, b, func(_ int, x, y string) string { return x + y })
+		testKeys(t, mp, u)
+		testReverse(t, mp, u)
+		testOrderStatistics(t, mp, u)
+		if !treeInvariant(u.endNode.left) {
+			t.Errorf("invariant error")
+		}
+	}
+}
+
+func TestRandomMultiMap(t *testing.T) {
+	tr := NewMulti[int, string]()
+	var exp []pair
+	for i, kv := range testRandomData()[:2000] {
+		switch {
+		case i%5 == 0:
+			n := 0
+			exp = slices.DeleteFunc(exp, func(p pair) bool {
+				if p.k == kv.k {
+					n++
+				}
+				return p.k == kv.k
+			})
+			if actual := tr.DelAll(kv.k); actual != n {
+				t.Errorf("wrong number of deleted elements, expected %d, got %d", n, actual)
+			}
+		case i%7 == 0:
+			if j := slices.IndexFunc(exp, func(p pair) bool { return p.k == kv.k }); j >= 0 {
+				exp = slices.Delete(exp, j, j+1)
+				first, _ := tr.EqualRange(kv.k)
+				tr.Erase(first)
+			}
+		default:
+			j, _ := slices.BinarySearchFunc(exp, kv.k+1, func(p pair, k int) int { return p.k - k })
+			exp = slices.Insert(exp, j, kv)
+			if it := tr.Insert(kv.k, kv.v); it.Key() != kv.k || it.Value() != kv.v {
+				t.Errorf("wrong inserted element, expected %d %s, got %d %s", kv.k, kv.v, it.Key(), it.Value())
+			}
+		}
+		var actual []pair
+		for k, v := range tr.All() {
+			actual = append(actual, pair{k, v})
+		}
+		if !slices.Equal(actual, exp) {
+			t.Fatalf("wrong elements, expected %v, got %v", exp, actual)
+		}
+		count := 0
+		for _, p := range exp {
+			if p.k == kv.k {
+				count++
+			}
+		}
+		if actual := tr.Count(kv.k); actual != count {
+			t.Errorf("wrong count, expected %d, got %d", count, actual)
+		}
+		if !treeInvariant(tr.m.endNode.left) {
+			t.Fatal("invariant error")
+		}
+	}
+}
+
+func TestRandomSetAlgebra(t *testing.T) {
+	for i := 0; i < 200; i++ {
+		a, b := NewSet[int](), NewSet[int]()
+		inA, inB := make(map[int]bool), make(map[int]bool)
+		for _, kv := range testRandomData()[:rand.Intn(100)] {
+			a.Add(kv.k)
+			inA[kv.k] = true
+		}
+		for _, kv := range testRandomData()[:rand.Intn(100)] {
+			b.Add(kv.k)
+			inB[kv.k] = true
+		}
+		var union, intersection, difference, symmetric []int
+		for k := 0; k < RandMax; k++ {
+			if inA[k] || inB[k] {
+				union = append(union, k)
+			}
+			if inA[k] && inB[k] {
+				intersection = append(intersection, k)
+			}
+			if inA[k] && !inB[k] {
+				difference = append(difference, k)
+			}
+			if inA[k] != inB[k] {
+				symmetric = append(symmetric, k)
+			}
+		}
+		if actual := slices.Collect(a.Union(b)); !slices.Equal(actual, union) {
+			t.Errorf("wrong union, expected %v, got %v", union, actual)
+		}
+		if actual := slices.Collect(a.Intersection(b)); !slices.Equal(actual, intersection) {
+			t.Errorf("wrong intersection, expected %v, got %v", intersection, actual)
+		}
+		if actual := slices.Collect(a.Difference(b)); !slices.Equal(actual, difference) {
+			t.Errorf("wrong difference, expected %v, got %v", difference, actual)
+		}
+		if actual := slices.Collect(a.SymmetricDifference(b)); !slices.Equal(actual, symmetric) {
+			t.Errorf("wrong symmetric difference, expected %v, got %v", symmetric, actual)
+		}
+	}
+}
+
+func TestRandomMultiset(t *testing.T) {
+	s := NewMultiset[int]()
+	counts := make(map[int]int)
+	for i, kv := range testRandomData()[:3000] {
+		n := kv.k % 4
+		if i%3 == 0 {
+			removed := n
+			if counts[kv.k] < n {
+				removed = counts[kv.k]
+			}
+			counts[kv.k] -= removed
+			if counts[kv.k] == 0 {
+				delete(counts, kv.k)
+			}
+			if actual := s.Remove(kv.k, n); actual != removed {
+				t.Errorf("wrong number of removed elements, expected %d, got %d", removed, actual)
+			}
+		} else {
+			counts[kv.k] += n
+			if counts[kv.k] == 0 {
+				delete(counts, kv.k)
+			}
+			s.Add(kv.k, n)
+		}
+		var exp []int
+		for k := 0; k < RandMax; k++ {
+			for j := 0; j < counts[k]; j++ {
+				exp = append(exp, k)
+			}
+		}
+		if s.Len() != len(exp) || s.Distinct() != len(counts) {
+			t.Fatalf("wrong sizes, expected %d %d, got %d %d", len(exp), len(counts), s.Len(), s.Distinct())
+		}
+		k := rand.Intn(len(exp) + 1)
+		if actual, ok := s.Select(k); ok != (k < len(exp)) || ok && actual != exp[k] {
+			t.Errorf("wrong %d-th element, got %d", k, actual)
+		}
+		if actual, exp := s.Rank(kv.k), sort.SearchInts(exp, kv.k); actual != exp {
+			t.Errorf("wrong rank, expected %d, got %d", exp, actual)
+		}
+		if !treeInvariant(s.m.endNode.left) || !multisetInvariant(s.m.endNode.left) {
+			t.Fatal("invariant error")
+		}
+	}
+}
+
+func TestRandomIntervalMap(t *testing.T) {
+	type entry struct {
+		in    Interval[int]
+		value int
+	}
+	m := NewIntervalMap[int, int]()
+	var exp []entry
+	for i, kv := range testRandomData()[:3000] {
+		lo := kv.k
+		hi := lo + rand.Intn(10)
+		if i%4 == 0 {
+			expDeleted := 0
+			exp = slices.DeleteFunc(exp, func(e entry) bool {
+				if e.in == (Interval[int]{lo, hi}) {
+					expDeleted++
+					return true
+				}
+				return false
+			})
+			if n := m.Del(lo, hi); n != expDeleted {
+				t.Fatalf("wrong number of deleted intervals, expected %d, got %d", expDeleted, n)
+			}
+		} else {
+			exp = append(exp, entry{Interval[int]{lo, hi}, i})
+			m.Insert(lo, hi, i)
+		}
+		from := rand.Intn(RandMax + 10)
+		to := from + rand.Intn(5)
+		var expOverlapping []entry
+		for _, e := range exp {
+			if e.in.Lo <= to && e.in.Hi >= from {
+				expOverlapping = append(expOverlapping, e)
+			}
+		}
+		slices.SortStableFunc(expOverlapping, func(a, b entry) int { return a.in.Lo - b.in.Lo })
+		var actual []entry
+		for in, v := range m.Overlapping(from, to) {
+			actual = append(actual, entry{in, v})
+		}
+		if !slices.Equal(actual, expOverlapping) {
+			t.Fatalf("wrong overlapping intervals, expected %v, got %v", expOverlapping, actual)
+		}
+		if m.Len() != len(exp) {
+			t.Fatalf("wrong count, expected %d, got %d", len(exp), m.Len())
+		}
+		if !treeInvariant(m.m.endNode.left) || !intervalInvariant(m.m.endNode.left) {
+			t.Fatal("invariant error")
+		}
+	}
+}
+
+func TestRandomAggregate(t *testing.T) {
+	sum := Monoid[int]{Combine: func(a, b int) int { return a + b }}
+	tr := NewAggregateMap[int, int](sum)
+	mp := make(map[int]int)
+	for i, kv := range testRandomData()[:5000] {
+		switch {
+		case i%5 == 0:
+			to := kv.k + rand.Intn(5)
+			tr.DelRange(kv.k, to)
+			for k := kv.k; k <= to; k++ {
+				delete(mp, k)
+			}
+		case i%3 == 0:
+			tr.Del(kv.k)
+			delete(mp, kv.k)
+		default:
+			tr.Set(kv.k, i)
+			mp[kv.k] = i
+		}
+		from := rand.Intn(RandMax)
+		to := from + rand.Intn(RandMax/2)
+		exp := 0
+		for k, v := range mp {
+			if k >= from && k <= to {
+				exp += v
+			}
+		}
+		if actual := tr.Aggregate(from, to); actual != exp {
+			t.Fatalf("wrong aggregate of [%d, %d], expected %d, got %d", from, to, exp, actual)
+		}
+		if !treeInvariant(tr.m.endNode.left) || !aggregateInvariant(tr.m.endNode.left, sum) {
+			t.Fatal("invariant error")
+		}
+	}
+}
+
+func TestRandomSetHint(t *testing.T) {
+	tr := New[int, string]()
+	mp := make(map[int]string)
+	hint := tr.End()
+	for i, kv := range testRandomData()[:5000] {
+		switch {
+		case i%5 == 0:
+			hint = tr.Select(rand.Intn(tr.Len() + 1))
+		case i%7 == 0:
+			tr.Del(kv.k)
+			delete(mp, kv.k)
+			hint = tr.End()
+			continue
+		}
+		hint = tr.SetHint(hint, kv.k, kv.v)
+		mp[kv.k] = kv.v
+		if hint.Key() != kv.k || hint.Value() != kv.v {
+			t.Fatalf("wrong returned element, expected %d %s, got %d %s", kv.k, kv.v, hint.Key(), hint.Value())
+		}
+		if rand.Intn(2) == 0 {
+			hint.Next()
+		}
+		if len(mp) != tr.Len() {
+			t.Fatalf("wrong count, expected %d, actual %d", len(mp), tr.Len())
+		}
+		if !treeInvariant(tr.endNode.left) {
+			t.Fatal("invariant error")
+		}
+	}
+	testKeys(t, mp, tr)
+	testOrderStatistics(t, mp, tr)
+}
+
+func TestRandomUpdate(t *testing.T) {
+	tr := New[int, string]()
+	mp := make(map[int]string)
+	for i, kv := range testRandomData() {
+		exp, expOK := mp[kv.k]
+		keep := i%3 != 0
+		tr.Update(kv.k, func(old string, exists bool) (string, bool) {
+			if old != exp || exists != expOK {
+				t.Errorf("wrong old value, expected %s, actual %s", exp, old)
+			}
+			return old + kv.v, keep
+		})
+		if keep {
+			mp[kv.k] = exp + kv.v
+		} else {
+			delete(mp, kv.k)
+		}
+		if len(mp) != tr.Len() {
+			t.Fatalf("wrong count, expected %d, actual %d", len(mp), tr.Len())
+		}
+		if !treeInvariant(tr.endNode.left) {
+			t.Fatal("invariant error")
+		}
+	}
+	testKeys(t, mp, tr)
+	for k, v := range mp {
+		if actual, _ := tr.Get(k); actual != v {
+			t.Errorf("wrong value of %d, expected %s, actual %s", k, v, actual)
+		}
+	}
+}
+
 func min(kv map[int]string) *int {
 	var key *int
 	for k := range kv {
@@ -118,6 +571,9 @@ func testMinMax(t *testing.T, mp map[int]string, tr *TreeMap[int, string]) {
 		temp := it.Key()
 		actual = &temp
 	}
+	if k, _, ok := tr.Max(); ok != (actual != nil) || ok && k != *actual {
+		t.Errorf("max does not match the reverse iterator")
+	}
 	if (exp == nil) != (actual == nil) {
 		t.Errorf("wrong max")
 	} else if exp != nil && actual != nil && *exp != *actual {
@@ -132,3 +588,36 @@ func testReverse(t *testing.T, mp map[int]string, tr *TreeMap[int, string]) {
 		}
 	}
 }
+
+func testOrderStatistics(t *testing.T, mp map[int]string, tr *TreeMap[int, string]) {
+	var keys []int
+	for k := range mp {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	for i, k := range keys {
+		if it := tr.Select(i); !it.Valid() || it.Key() != k {
+			t.Errorf("wrong selected key at %d, expected %d", i, k)
+		}
+		if r := tr.Rank(k); r != i {
+			t.Errorf("wrong rank of %d, expected %d, actual %d", k, i, r)
+		}
+		if d := tr.Distance(tr.Iterator(), tr.Select(i)); d != i {
+			t.Errorf("wrong distance to %d, expected %d, actual %d", k, i, d)
+		}
+	}
+	from := rand.Intn(RandMax)
+	to := from + rand.Intn(RandMax/2)
+	for _, bounds := range []Bounds{BoundsClosed, BoundsOpen, BoundsClosedOpen, BoundsOpenClosed} {
+		exp := 0
+		for _, k := range keys {
+			if (k > from || k == from && (bounds == BoundsClosed || bounds == BoundsClosedOpen)) &&
+				(k < to || k == to && (bounds == BoundsClosed || bounds == BoundsOpenClosed)) {
+				exp++
+			}
+		}
+		if n := tr.CountRange(from, to, bounds); n != exp {
+			t.Errorf("wrong count of %d..%d with bounds %d, expected %d, actual %d", from, to, bounds, exp, n)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package treemap
+
+import (
+	"iter"
+
+	"golang.org/x/exp/constraints"
+)
+
+// TreeSet is the generic key-sorted set.
+// It shares the red-black tree with TreeMap and stores zero-size values.
+type TreeSet[Key any] struct {
+	m *TreeMap[Key, struct{}]
+}
+
+// NewSet creates and returns new TreeSet.
+func NewSet[Key constraints.Ordered]() *TreeSet[Key] {
+	return &TreeSet[Key]{m: New[Key, struct{}]()}
+}
+
+// NewSetWithKeyCompare creates and returns new TreeSet with the specified key compare function.
+// Parameter keyCompare is a function returning a < b.
+func NewSetWithKeyCompare[Key any](
+	keyCompare func(a, b Key) bool,
+) *TreeSet[Key] {
+	return &TreeSet[Key]{m: NewWithKeyCompare[Key, struct{}](keyCompare)}
+}
+
+// Len returns total count of keys in a set.
+// Complexity: O(1).
+func (s *TreeSet[Key]) Len() int { return s.m.Len() }
+
+// Add adds the key to a set and reports if it was not there.
+// Complexity: O(log N).
+func (s *TreeSet[Key]) Add(key Key) bool { return s.m.SetIfAbsent(key, struct{}{}) }
+
+// Remove removes the key from a set and reports if it was there.
+// Complexity: O(log N).
+func (s *TreeSet[Key]) Remove(key Key) bool {
+	s.m.startWrite()
+	defer s.m.endWrite()
+	z := s.m.findNode(key)
+	if z == nil {
+		return false
+	}
+	s.m.erase(z)
+	return true
+}
+
+// Has checks if the key exists in a set.
+// Complexity: O(log N).
+func (s *TreeSet[Key]) Has(key Key) bool { return s.m.Contains(key) }
+
+// Clear clears the set.
+// Complexity: O(1).
+func (s *TreeSet[Key]) Clear() { s.m.Clear() }
+
+// Clone returns a copy of the set.
+// Complexity: O(N).
+func (s *TreeSet[Key]) Clone() *TreeSet[Key] { return &TreeSet[Key]{m: s.m.Clone()} }
+
+// Min returns the least key and reports if the set is not empty.
+// Complexity: O(1).
+func (s *TreeSet[Key]) Min() (Key, bool) { return setEntry(s.m.Min()) }
+
+// Max returns the greatest key and reports if the set is not empty.
+// Complexity: O(1).
+func (s *TreeSet[Key]) Max() (Key, bool) { return setEntry(s.m.Max()) }
+
+// Floor returns the greatest key that is less than or equal to the given key.
+// It reports if such key exists.
+// Complexity: O(log N).
+func (s *TreeSet[Key]) Floor(key Key) (Key, bool) { return setEntry(s.m.Floor(key)) }
+
+// Ceiling returns the least key that is greater than or equal to the given key.
+// It reports if such key exists.
+// Complexity: O(log N).
+func (s *TreeSet[Key]) Ceiling(key Key) (Key, bool) { return setEntry(s.m.Ceiling(key)) }
+
+// Lower returns the greatest key that is strictly less than the given key.
+// It reports if such key exists.
+// Complexity: O(log N).
+func (s *TreeSet[Key]) Lower(key Key) (Key, bool) { return setEntry(s.m.Lower(key)) }
+
+// Higher returns the least key that is strictly greater than the given key.
+// It reports if such key exists.
+// Complexity: O(log N).
+func (s *TreeSet[Key]) Higher(key Key) (Key, bool) { return setEntry(s.m.Higher(key)) }
+
+// Range returns a pair of iterators that you can use to go through all the keys in the range [from, to].
+// More specifically it returns iterators pointing to lower bound and upper bound.
+// Complexity: O(log N).
+func (s *TreeSet[Key]) Range(from, to Key) (SetIterator[Key], SetIterator[Key]) {
+	return s.LowerBound(from), s.UpperBound(to)
+}
+
+// LowerBound returns an iterator pointing to the first key that is not less than the given key.
+// Complexity: O(log N).
+func (s *TreeSet[Key]) LowerBound(key Key) SetIterator[Key] {
+	return SetIterator[Key]{it: s.m.LowerBound(key)}
+}
+
+// UpperBound returns an iterator pointing to the first key that is greater than the given key.
+// Complexity: O(log N).
+func (s *TreeSet[Key]) UpperBound(key Key) SetIterator[Key] {
+	return SetIterator[Key]{it: s.m.UpperBound(key)}
+}
+
+// Iterator returns an iterator for a set.
+// It starts at the first key and goes to the one-past-the-end position.
+// Method complexity: O(1)
+func (s *TreeSet[Key]) Iterator() SetIterator[Key] { return SetIterator[Key]{it: s.m.Iterator()} }
+
+// End returns an iterator pointing to the one-past-the-end position.
+// Complexity: O(1)
+func (s *TreeSet[Key]) End() SetIterator[Key] { return SetIterator[Key]{it: s.m.End()} }
+
+// Reverse returns a reverse iterator for a set.
+// It starts at the last key and goes to the one-before-the-start position.
+// Method complexity: O(1)
+func (s *TreeSet[Key]) Reverse() SetReverseIterator[Key] {
+	return SetReverseIterator[Key]{it: s.m.Reverse()}
+}
+
+// All returns an iterator over keys in ascending order.
+func (s *TreeSet[Key]) All() iter.Seq[Key] { return s.m.Keys() }
+
+// Backward returns an iterator over keys in descending order.
+func (s *TreeSet[Key]) Backward() iter.Seq[Key] {
+	return func(yield func(Key) bool) {
+		for k := range s.m.Backward() {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+// AllRange returns an iterator over keys in the range [from, to] in ascending order.
+func (s *TreeSet[Key]) AllRange(from, to Key) iter.Seq[Key] {
+	return func(yield func(Key) bool) {
+		for k := range s.m.AllRange(from, to) {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+// Union returns an iterator over keys that are in s or in other in ascending order.
+// Keys are computed lazily, so the sets must not be modified during the iteration.
+// Both sets must use the same key order.
+func (s *TreeSet[Key]) Union(other *TreeSet[Key]) iter.Seq[Key] {
+	return s.merge(other, true, true, true)
+}
+
+// Intersection returns an iterator over keys that are both in s and in other in ascending order.
+// Keys are computed lazily, so the sets must not be modified during the iteration.
+// Both sets must use the same key order.
+func (s *TreeSet[Key]) Intersection(other *TreeSet[Key]) iter.Seq[Key] {
+	return s.merge(other, false, false, true)
+}
+
+// Difference returns an iterator over keys that are in s but not in other in ascending order.
+// Keys are computed lazily, so the sets must not be modified during the iteration.
+// Both sets must use the same key order.
+func (s *TreeSet[Key]) Difference(other *TreeSet[Key]) iter.Seq[Key] {
+	return s.merge(other, true, false, false)
+}
+
+// SymmetricDifference returns an iterator over keys that are in exactly one of the sets in ascending order.
+// Keys are computed lazily, so the sets must not be modified during the iteration.
+// Both sets must use the same key order.
+func (s *TreeSet[Key]) SymmetricDifference(other *TreeSet[Key]) iter.Seq[Key] {
+	return s.merge(other, true, true, false)
+}
+
+// merge walks both sets at once yielding keys present only in s, only in other or in both of them
+func (s *TreeSet[Key]) merge(other *TreeSet[Key], keepA, keepB, keepBoth bool) iter.Seq[Key] {
+	return func(yield func(Key) bool) {
+		a, b := s.m, other.m
+		x, y := a.beginNode, b.beginNode
+		for x != a.endNode && y != b.endNode {
+			switch {
+			case a.keyCompare(x.key, y.key):
+				if keepA && !yield(x.key) {
+					return
+				}
+				x = successor(x)
+			case a.keyCompare(y.key, x.key):
+				if keepB && !yield(y.key) {
+					return
+				}
+				y = successor(y)
+			default:
+				if keepBoth && !yield(x.key) {
+					return
+				}
+				x = successor(x)
+				y = successor(y)
+			}
+		}
+		for ; keepA && x != a.endNode; x = successor(x) {
+			if !yield(x.key) {
+				return
+			}
+		}
+		for ; keepB && y != b.endNode; y = successor(y) {
+			if !yield(y.key) {
+				return
+			}
+		}
+	}
+}
+
+// CollectSet creates and returns new TreeSet filled with keys from seq.
+// Complexity: O(N log N).
+func CollectSet[Key constraints.Ordered](seq iter.Seq[Key]) *TreeSet[Key] {
+	return CollectSetWithKeyCompare(seq, defaultKeyCompare[Key])
+}
+
+// CollectSetWithKeyCompare creates and returns new TreeSet with the specified key compare function
+// filled with keys from seq.
+// Complexity: O(N log N).
+func CollectSetWithKeyCompare[Key any](
+	seq iter.Seq[Key],
+	keyCompare func(a, b Key) bool,
+) *TreeSet[Key] {
+	s := NewSetWithKeyCompare(keyCompare)
+	for k := range seq {
+		s.Add(k)
+	}
+	return s
+}
+
+func setEntry[Key any](
+	key Key,
+	_ struct{},
+	ok bool,
+) (Key, bool) {
+	return key, ok
+}
+
+// SetIterator represents a position in a tree set.
+// It is designed to iterate a set in a forward order.
+// It can point to any position from the first key to the one-past-the-end key.
+type SetIterator[Key any] struct {
+	it ForwardIterator[Key, struct{}]
+}
+
+// Valid reports if the iterator position is valid.
+// In other words it returns true if an iterator is not at the one-past-the-end position.
+func (i SetIterator[Key]) Valid() bool { return i.it.Valid() }
+
+// Next moves an iterator to the next key.
+// It panics if it goes out of bounds.
+func (i *SetIterator[Key]) Next() { i.it.Next() }
+
+// Prev moves an iterator to the previous key.
+// It panics if it goes out of bounds.
+func (i *SetIterator[Key]) Prev() { i.it.Prev() }
+
+// Reverse returns a reverse iterator pointing to the same key.
+// If the iterator is at the one-past-the-end position then the result is at the one-before-the-start position.
+func (i SetIterator[Key]) Reverse() SetReverseIterator[Key] {
+	return SetReverseIterator[Key]{it: i.it.Reverse()}
+}
+
+// Key returns a key at the iterator position
+func (i SetIterator[Key]) Key() Key { return i.it.Key() }
+
+// Err returns ErrInvalidIterator if the iterator was invalidated by a modification of the set and nil otherwise.
+func (i SetIterator[Key]) Err() error { return i.it.Err() }
+
+// SetReverseIterator represents a position in a tree set.
+// It is designed to iterate a set in a reverse order.
+// It can point to any position from the one-before-the-start key to the last key.
+type SetReverseIterator[Key any] struct {
+	it ReverseIterator[Key, struct{}]
+}
+
+// Valid reports if the iterator position is valid.
+// In other words it returns true if an iterator is not at the one-before-the-start position.
+func (i SetReverseIterator[Key]) Valid() bool { return i.it.Valid() }
+
+// Next moves an iterator to the next key in reverse order.
+// It panics if it goes out of bounds.
+func (i *SetReverseIterator[Key]) Next() { i.it.Next() }
+
+// Prev moves an iterator to the previous key in reverse order.
+// It panics if it goes out of bounds.
+func (i *SetReverseIterator[Key]) Prev() { i.it.Prev() }
+
+// Forward returns a forward iterator pointing to the same key.
+// If the iterator is at the one-before-the-start position then the result is at the one-past-the-end position.
+func (i SetReverseIterator[Key]) Forward() SetIterator[Key] {
+	return SetIterator[Key]{it: i.it.Forward()}
+}
+
+// Key returns a key at the iterator position
+func (i SetReverseIterator[Key]) Key() Key { return i.it.Key() }
+
+// Err returns ErrInvalidIterator if the iterator was invalidated by a modification of the set and nil otherwise.
+func (i SetReverseIterator[Key]) Err() error { return i.it.Err() }
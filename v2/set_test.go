@@ -0,0 +1,98 @@
+package treemap
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTreeSet(t *testing.T) {
+	testTreeSet(t, NewSet[int]())
+	testTreeSet(t, NewSetWithKeyCompare[int](less))
+}
+
+func TestTreeSetAlgebra(t *testing.T) {
+	a := CollectSet(slices.Values([]int{1, 2, 3, 5}))
+	b := CollectSetWithKeyCompare(slices.Values([]int{2, 4, 5, 6}), less)
+	tests := []struct {
+		name string
+		keys []int
+		exp  []int
+	}{
+		{"union", slices.Collect(a.Union(b)), []int{1, 2, 3, 4, 5, 6}},
+		{"intersection", slices.Collect(a.Intersection(b)), []int{2, 5}},
+		{"difference", slices.Collect(a.Difference(b)), []int{1, 3}},
+		{"symmetric difference", slices.Collect(a.SymmetricDifference(b)), []int{1, 3, 4, 6}},
+		{"empty", slices.Collect(a.Intersection(NewSet[int]())), nil},
+	}
+	for _, test := range tests {
+		if !slices.Equal(test.keys, test.exp) {
+			t.Errorf("wrong %s, expected %v, got %v", test.name, test.exp, test.keys)
+		}
+	}
+	for k := range a.Union(b) {
+		if k == 3 {
+			break
+		}
+	}
+}
+
+func testTreeSet(t *testing.T, s *TreeSet[int]) {
+	for _, k := range []int{5, 1, 3, 7} {
+		if !s.Add(k) {
+			t.Errorf("%d should be added", k)
+		}
+	}
+	if s.Add(3) {
+		t.Error("3 should not be added twice")
+	}
+	if s.Len() != 4 || !s.Has(3) || s.Has(4) {
+		t.Error("wrong set contents")
+	}
+	if !s.Remove(7) || s.Remove(7) {
+		t.Error("7 should be removed once")
+	}
+	if k, ok := s.Min(); !ok || k != 1 {
+		t.Errorf("wrong min, got %d", k)
+	}
+	if k, ok := s.Max(); !ok || k != 5 {
+		t.Errorf("wrong max, got %d", k)
+	}
+	if k, ok := s.Floor(4); !ok || k != 3 {
+		t.Errorf("wrong floor, got %d", k)
+	}
+	if k, ok := s.Ceiling(4); !ok || k != 5 {
+		t.Errorf("wrong ceiling, got %d", k)
+	}
+	if k, ok := s.Lower(1); ok {
+		t.Errorf("lower should not exist, got %d", k)
+	}
+	if k, ok := s.Higher(5); ok {
+		t.Errorf("higher should not exist, got %d", k)
+	}
+	var keys []int
+	for it, end := s.Range(2, 5); it != end; it.Next() {
+		keys = append(keys, it.Key())
+	}
+	for it := s.Reverse(); it.Valid(); it.Next() {
+		keys = append(keys, it.Key())
+	}
+	keys = append(keys, slices.Collect(s.Backward())...)
+	keys = append(keys, slices.Collect(s.AllRange(0, 3))...)
+	exp := []int{3, 5, 5, 3, 1, 5, 3, 1, 1, 3}
+	if !slices.Equal(keys, exp) {
+		t.Errorf("wrong keys, expected %v, got %v", exp, keys)
+	}
+	it := s.End()
+	it.Prev()
+	if r := it.Reverse(); r.Key() != 5 || r.Forward() != it {
+		t.Error("wrong iterator conversion")
+	}
+	c := s.Clone()
+	s.Clear()
+	if s.Len() != 0 || s.Iterator() != s.End() {
+		t.Error("set should be empty")
+	}
+	if !slices.Equal(slices.Collect(c.All()), []int{1, 3, 5}) {
+		t.Error("clone should be intact")
+	}
+}
@@ -0,0 +1,137 @@
+package treemap
+
+import "iter"
+
+// Snapshot is a read-only view of a TreeMap frozen at the moment it was taken.
+// It is safe to read a snapshot from other goroutines while the map is being modified,
+// since the map copies the nodes shared with snapshots before modifying them.
+type Snapshot[Key, Value any] struct {
+	root       *node[Key, Value]
+	count      int
+	keyCompare func(a Key, b Key) bool
+}
+
+// Snapshot returns a read-only view of the map frozen at this moment.
+// Subsequent modifications of the map copy only the nodes they touch.
+// Iterators of the map are invalidated by the first modification after taking a snapshot.
+// Complexity: O(1).
+func (t *TreeMap[Key, Value]) Snapshot() *Snapshot[Key, Value] {
+	t.startWrite()
+	defer t.endWrite()
+	s := &Snapshot[Key, Value]{root: t.endNode.left, count: t.count, keyCompare: t.keyCompare}
+	t.epoch++
+	return s
+}
+
+// Len returns total count of elements in a snapshot.
+// Complexity: O(1).
+func (s *Snapshot[Key, Value]) Len() int { return s.count }
+
+// Get retrieves a value from a snapshot for specified key and reports if it exists.
+// Complexity: O(log N).
+func (s *Snapshot[Key, Value]) Get(key Key) (Value, bool) {
+	current := s.root
+	for current != nil {
+		switch {
+		case s.keyCompare(key, current.key):
+			current = current.left
+		case s.keyCompare(current.key, key):
+			current = current.right
+		default:
+			return current.value, true
+		}
+	}
+	var value Value
+	return value, false
+}
+
+// Contains checks if key exists in a snapshot.
+// Complexity: O(log N)
+func (s *Snapshot[Key, Value]) Contains(key Key) bool {
+	_, ok := s.Get(key)
+	return ok
+}
+
+// All returns an iterator over key-value pairs in ascending key order.
+func (s *Snapshot[Key, Value]) All() iter.Seq2[Key, Value] {
+	return func(yield func(Key, Value) bool) {
+		s.ascend(s.root, nil, nil, yield)
+	}
+}
+
+// Backward returns an iterator over key-value pairs in descending key order.
+func (s *Snapshot[Key, Value]) Backward() iter.Seq2[Key, Value] {
+	return func(yield func(Key, Value) bool) {
+		s.descend(s.root, nil, nil, yield)
+	}
+}
+
+// Keys returns an iterator over keys in ascending order.
+func (s *Snapshot[Key, Value]) Keys() iter.Seq[Key] {
+	return func(yield func(Key) bool) {
+		s.ascend(s.root, nil, nil, func(key Key, _ Value) bool { return yield(key) })
+	}
+}
+
+// Values returns an iterator over values in ascending key order.
+func (s *Snapshot[Key, Value]) Values() iter.Seq[Value] {
+	return func(yield func(Value) bool) {
+		s.ascend(s.root, nil, nil, func(_ Key, value Value) bool { return yield(value) })
+	}
+}
+
+// AllRange returns an iterator over key-value pairs with keys in the range [from, to] in ascending key order.
+func (s *Snapshot[Key, Value]) AllRange(from, to Key) iter.Seq2[Key, Value] {
+	return func(yield func(Key, Value) bool) {
+		s.ascend(s.root, &from, &to, yield)
+	}
+}
+
+// BackwardRange returns an iterator over key-value pairs with keys in the range [from, to] in descending key order.
+func (s *Snapshot[Key, Value]) BackwardRange(from, to Key) iter.Seq2[Key, Value] {
+	return func(yield func(Key, Value) bool) {
+		s.descend(s.root, &from, &to, yield)
+	}
+}
+
+// ascend yields the elements of a subtree with keys in the range [from, to] in ascending order.
+// Nil bounds are unbounded. It reports if the iteration should go on.
+func (s *Snapshot[Key, Value]) ascend(x *node[Key, Value], from, to *Key, yield func(Key, Value) bool) bool {
+	for x != nil {
+		if from != nil && s.keyCompare(x.key, *from) {
+			x = x.right
+			continue
+		}
+		if to != nil && s.keyCompare(*to, x.key) {
+			x = x.left
+			continue
+		}
+		if !s.ascend(x.left, from, nil, yield) || !yield(x.key, x.value) {
+			return false
+		}
+		from = nil
+		x = x.right
+	}
+	return true
+}
+
+// descend yields the elements of a subtree with keys in the range [from, to] in descending order.
+// Nil bounds are unbounded. It reports if the iteration should go on.
+func (s *Snapshot[Key, Value]) descend(x *node[Key, Value], from, to *Key, yield func(Key, Value) bool) bool {
+	for x != nil {
+		if to != nil && s.keyCompare(*to, x.key) {
+			x = x.left
+			continue
+		}
+		if from != nil && s.keyCompare(x.key, *from) {
+			x = x.right
+			continue
+		}
+		if !s.descend(x.right, nil, to, yield) || !yield(x.key, x.value) {
+			return false
+		}
+		to = nil
+		x = x.left
+	}
+	return true
+}
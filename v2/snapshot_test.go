@@ -0,0 +1,260 @@
+package treemap
+
+import (
+	"maps"
+	"math/rand"
+	"reflect"
+	"slices"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	testSnapshot(t, New[int, string]())
+	testSnapshot(t, NewWithKeyCompare[int, string](less))
+}
+
+func TestSnapshotRange(t *testing.T) {
+	testSnapshotRange(t, New[int, string]())
+	testSnapshotRange(t, NewWithKeyCompare[int, string](less))
+}
+
+func TestSnapshotDeleteWhileIterating(t *testing.T) {
+	testSnapshotDeleteWhileIterating(t, New[int, string]())
+	testSnapshotDeleteWhileIterating(t, NewWithKeyCompare[int, string](less))
+}
+
+func TestSnapshotRandom(t *testing.T) {
+	tr := New[int, string]()
+	mp := make(map[int]string)
+	type version struct {
+		s  *Snapshot[int, string]
+		mp map[int]string
+	}
+	var versions []version
+	for i, kv := range testRandomData() {
+		switch {
+		case i%3 == 0 && (i/200)%2 == 0:
+			tr.Set(kv.k, kv.v)
+			mp[kv.k] = kv.v
+		case i%50 == 0:
+			to := kv.k + rand.Intn(5)
+			tr.DelRange(kv.k, to)
+			for k := range mp {
+				if k >= kv.k && k <= to {
+					delete(mp, k)
+				}
+			}
+		case i%70 == 0:
+			left, right := tr.Split(kv.k)
+			tr = Join(left, right)
+		case i%11 == 0:
+			if it := tr.LowerBound(kv.k); it.Valid() {
+				delete(mp, it.Key())
+				tr.Erase(it)
+			}
+		default:
+			tr.Del(kv.k)
+			delete(mp, kv.k)
+		}
+		if !treeInvariant(tr.endNode.left) {
+			t.Errorf("invariant error")
+			return
+		}
+		testKeys(t, mp, tr)
+		testMinMax(t, mp, tr)
+		testOrderStatistics(t, mp, tr)
+		if i%20 == 0 {
+			versions = append(versions, version{tr.Snapshot(), maps.Clone(mp)})
+		}
+	}
+	for _, v := range versions {
+		testSnapshotContent(t, v.mp, v.s)
+	}
+}
+
+func TestSnapshotConcurrentReads(t *testing.T) {
+	tr := New[int, int]()
+	for i := 0; i < 1000; i++ {
+		tr.Set(i, i)
+	}
+	s := tr.Snapshot()
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 20; n++ {
+				count := 0
+				for k, v := range s.All() {
+					if k != count || v != count {
+						t.Errorf("wrong element, expected %d, got %d %d", count, k, v)
+						return
+					}
+					count++
+				}
+				if count != 1000 {
+					t.Errorf("wrong count, expected 1000, got %d", count)
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 2000; i++ {
+		k := rand.Intn(2000)
+		if i%2 == 0 {
+			tr.Set(k, -k)
+		} else {
+			tr.Del(k)
+		}
+	}
+	wg.Wait()
+}
+
+func testSnapshotContent(t *testing.T, mp map[int]string, s *Snapshot[int, string]) {
+	if s.Len() != len(mp) {
+		t.Errorf("wrong snapshot count, expected %d, actual %d", len(mp), s.Len())
+	}
+	var expKeys []int
+	for k, v := range mp {
+		expKeys = append(expKeys, k)
+		if actual, ok := s.Get(k); !ok || actual != v {
+			t.Errorf("wrong snapshot value, expected %s, actual %s", v, actual)
+		}
+	}
+	sort.Ints(expKeys)
+	actualKeys := slices.Collect(s.Keys())
+	if len(expKeys) != 0 && !reflect.DeepEqual(actualKeys, expKeys) {
+		t.Errorf("wrong snapshot keys, expected %v, actual %v", expKeys, actualKeys)
+	}
+	actualKeys = actualKeys[:0]
+	for k := range s.Backward() {
+		actualKeys = append(actualKeys, k)
+	}
+	slices.Reverse(actualKeys)
+	if len(expKeys) != 0 && !reflect.DeepEqual(actualKeys, expKeys) {
+		t.Errorf("wrong snapshot reverse keys, expected %v, actual %v", expKeys, actualKeys)
+	}
+}
+
+func testSnapshot(t *testing.T, tr *TreeMap[int, string]) {
+	for i := 0; i < 100; i++ {
+		tr.Set(i, "x")
+	}
+	s := tr.Snapshot()
+	for i := 0; i < 100; i += 2 {
+		tr.Del(i)
+	}
+	tr.Set(1, "y")
+	tr.Set(1000, "z")
+	tr.PopMin()
+	if s.Len() != 100 {
+		t.Errorf("wrong snapshot count, expected 100, got %d", s.Len())
+	}
+	for i := 0; i < 100; i++ {
+		if v, ok := s.Get(i); !ok || v != "x" {
+			t.Errorf("wrong snapshot value of %d, expected 'x', got '%s'", i, v)
+		}
+	}
+	if s.Contains(1000) {
+		t.Error("snapshot should not see new keys")
+	}
+	if !treeInvariant(tr.endNode.left) {
+		t.Error("invariant error")
+	}
+	exp := []int{}
+	for i := 3; i < 100; i += 2 {
+		exp = append(exp, i)
+	}
+	testKeysEqual(t, tr, append(exp, 1000))
+}
+
+func testSnapshotRange(t *testing.T, tr *TreeMap[int, string]) {
+	for i := 0; i < 20; i++ {
+		tr.Set(i*2, "x")
+	}
+	s := tr.Snapshot()
+	tr.Clear()
+	tbl := []struct {
+		seq func(yield func(int, string) bool)
+		exp []int
+	}{
+		{s.AllRange(3, 9), []int{4, 6, 8}},
+		{s.AllRange(4, 8), []int{4, 6, 8}},
+		{s.AllRange(9, 3), nil},
+		{s.BackwardRange(3, 9), []int{8, 6, 4}},
+		{s.BackwardRange(-5, 2), []int{2, 0}},
+		{s.BackwardRange(100, 200), nil},
+	}
+	for i, tb := range tbl {
+		var keys []int
+		for k := range tb.seq {
+			keys = append(keys, k)
+		}
+		if !slices.Equal(keys, tb.exp) {
+			t.Errorf("wrong keys in range #%d, expected %v, got %v", i, tb.exp, keys)
+		}
+	}
+	var keys []int
+	for k := range s.All() {
+		if k > 4 {
+			break
+		}
+		keys = append(keys, k)
+	}
+	if !slices.Equal(keys, []int{0, 2, 4}) {
+		t.Errorf("wrong keys before break, got %v", keys)
+	}
+}
+
+func testSnapshotDeleteWhileIterating(t *testing.T, tr *TreeMap[int, string]) {
+	for n := 1; n <= 50; n++ {
+		var exp []int
+		for i := 0; i < n; i++ {
+			tr.Set(i, "x")
+			exp = append(exp, i)
+		}
+		s := tr.Snapshot()
+		var keys []int
+		for k := range tr.All() {
+			keys = append(keys, k)
+			tr.Del(k)
+		}
+		if !slices.Equal(keys, exp) || tr.Len() != 0 {
+			t.Errorf("wrong forward keys for %d elements, got %v, %d left", n, keys, tr.Len())
+		}
+		if s.Len() != n {
+			t.Errorf("wrong snapshot count, expected %d, got %d", n, s.Len())
+		}
+		for i := 0; i < n; i++ {
+			tr.Set(i, "x")
+		}
+		tr.Snapshot()
+		keys = nil
+		for k := range tr.Backward() {
+			keys = append(keys, k)
+			tr.Del(k)
+		}
+		slices.Reverse(keys)
+		if !slices.Equal(keys, exp) || tr.Len() != 0 {
+			t.Errorf("wrong backward keys for %d elements, got %v, %d left", n, keys, tr.Len())
+		}
+		for i := 0; i < n; i++ {
+			tr.Set(i, "x")
+		}
+		tr.Snapshot()
+		keys = nil
+		for k := range tr.AllRange(1, n-2) {
+			keys = append(keys, k)
+			tr.Del(k)
+		}
+		if n > 2 && (!slices.Equal(keys, exp[1:n-1]) || tr.Len() != 2) {
+			t.Errorf("wrong range keys for %d elements, got %v, %d left", n, keys, tr.Len())
+		}
+		if !treeInvariant(tr.endNode.left) {
+			t.Fatal("invariant error")
+		}
+		tr.Clear()
+	}
+}
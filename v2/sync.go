@@ -0,0 +1,404 @@
+package treemap
+
+import (
+	"iter"
+	"sync"
+
+	"golang.org/x/exp/constraints"
+)
+
+// SyncTreeMap is the generic key-sorted map protected by a read-write mutex.
+// It is safe for concurrent use by multiple goroutines.
+// It doesn't expose iterators since they would escape the lock.
+// Iterate with All and friends holding the read lock, or take a Snapshot.
+type SyncTreeMap[Key, Value any] struct {
+	mu sync.RWMutex
+	m  *TreeMap[Key, Value]
+}
+
+// NewSync creates and returns new SyncTreeMap.
+func NewSync[Key constraints.Ordered, Value any]() *SyncTreeMap[Key, Value] {
+	return &SyncTreeMap[Key, Value]{m: New[Key, Value]()}
+}
+
+// NewSyncWithKeyCompare creates and returns new SyncTreeMap with the specified key compare function.
+// Parameter keyCompare is a function returning a < b.
+func NewSyncWithKeyCompare[Key, Value any](
+	keyCompare func(a, b Key) bool,
+) *SyncTreeMap[Key, Value] {
+	return &SyncTreeMap[Key, Value]{m: NewWithKeyCompare[Key, Value](keyCompare)}
+}
+
+// Len returns total count of elements in a map.
+// Complexity: O(1).
+func (s *SyncTreeMap[Key, Value]) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.m.Len()
+}
+
+// Set sets the value and silently overrides previous value if it exists.
+// Complexity: O(log N).
+func (s *SyncTreeMap[Key, Value]) Set(key Key, value Value) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.m.Set(key, value)
+}
+
+// Del deletes the value.
+// Complexity: O(log N).
+func (s *SyncTreeMap[Key, Value]) Del(key Key) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.m.Del(key)
+}
+
+// DelRange deletes all the keys in the range [from, to] and returns the number of deleted elements.
+// Complexity: O(log N).
+func (s *SyncTreeMap[Key, Value]) DelRange(from, to Key) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.m.DelRange(from, to)
+}
+
+// DelBefore deletes all the keys less than the given key and returns the number of deleted elements.
+// Complexity: O(log N).
+func (s *SyncTreeMap[Key, Value]) DelBefore(key Key) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.m.DelBefore(key)
+}
+
+// DelFrom deletes all the keys not less than the given key and returns the number of deleted elements.
+// Complexity: O(log N).
+func (s *SyncTreeMap[Key, Value]) DelFrom(key Key) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.m.DelFrom(key)
+}
+
+// Clear clears the map.
+// Complexity: O(1).
+func (s *SyncTreeMap[Key, Value]) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.m.Clear()
+}
+
+// Get retrieves a value from a map for specified key and reports if it exists.
+// Complexity: O(log N).
+func (s *SyncTreeMap[Key, Value]) Get(key Key) (Value, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.m.Get(key)
+}
+
+// Contains checks if key exists in a map.
+// Complexity: O(log N)
+func (s *SyncTreeMap[Key, Value]) Contains(key Key) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.m.Contains(key)
+}
+
+// Min returns the least key along with its value.
+// It reports if the map is not empty.
+// Complexity: O(1).
+func (s *SyncTreeMap[Key, Value]) Min() (Key, Value, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.m.Min()
+}
+
+// Max returns the greatest key along with its value.
+// It reports if the map is not empty.
+// Complexity: O(1).
+func (s *SyncTreeMap[Key, Value]) Max() (Key, Value, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.m.Max()
+}
+
+// PopMin removes the least key from a map and returns it along with its value.
+// It reports if the map was not empty.
+// Complexity: O(log N).
+func (s *SyncTreeMap[Key, Value]) PopMin() (Key, Value, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.m.PopMin()
+}
+
+// PopMax removes the greatest key from a map and returns it along with its value.
+// It reports if the map was not empty.
+// Complexity: O(log N).
+func (s *SyncTreeMap[Key, Value]) PopMax() (Key, Value, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.m.PopMax()
+}
+
+// Floor returns the greatest key that is less than or equal to the given key along with its value.
+// It reports if such key exists.
+// Complexity: O(log N).
+func (s *SyncTreeMap[Key, Value]) Floor(key Key) (Key, Value, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.m.Floor(key)
+}
+
+// Ceiling returns the least key that is greater than or equal to the given key along with its value.
+// It reports if such key exists.
+// Complexity: O(log N).
+func (s *SyncTreeMap[Key, Value]) Ceiling(key Key) (Key, Value, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.m.Ceiling(key)
+}
+
+// Lower returns the greatest key that is strictly less than the given key along with its value.
+// It reports if such key exists.
+// Complexity: O(log N).
+func (s *SyncTreeMap[Key, Value]) Lower(key Key) (Key, Value, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.m.Lower(key)
+}
+
+// Higher returns the least key that is strictly greater than the given key along with its value.
+// It reports if such key exists.
+// Complexity: O(log N).
+func (s *SyncTreeMap[Key, Value]) Higher(key Key) (Key, Value, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.m.Higher(key)
+}
+
+// Rank returns the number of keys that are less than the given key.
+// Complexity: O(log N).
+func (s *SyncTreeMap[Key, Value]) Rank(key Key) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.m.Rank(key)
+}
+
+// Select returns the k-th smallest key along with its value, counting from zero.
+// It reports if k is in range.
+// Complexity: O(log N).
+func (s *SyncTreeMap[Key, Value]) Select(k int) (Key, Value, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	it := s.m.Select(k)
+	if !it.Valid() {
+		var key Key
+		var value Value
+		return key, value, false
+	}
+	return it.Key(), it.Value(), true
+}
+
+// GetOrSet returns the existing value for the key if present.
+// Otherwise, it sets and returns the given value.
+// The loaded result is true if the value was loaded, false if set.
+// Complexity: O(log N).
+func (s *SyncTreeMap[Key, Value]) GetOrSet(key Key, value Value) (actual Value, loaded bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if v, ok := s.m.Get(key); ok {
+		return v, true
+	}
+	s.m.Set(key, value)
+	return value, false
+}
+
+// SetIfAbsent sets the value only if the key doesn't exist and reports if it was set.
+// Complexity: O(log N).
+func (s *SyncTreeMap[Key, Value]) SetIfAbsent(key Key, value Value) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.m.SetIfAbsent(key, value)
+}
+
+// Swap sets the value and returns the previous one reporting if it existed.
+// Complexity: O(log N).
+func (s *SyncTreeMap[Key, Value]) Swap(key Key, value Value) (old Value, existed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.m.Swap(key, value)
+}
+
+// Take deletes the key and returns its value reporting if it existed.
+// Complexity: O(log N).
+func (s *SyncTreeMap[Key, Value]) Take(key Key) (Value, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.m.Take(key)
+}
+
+// Update atomically inserts, modifies or deletes the value for the key the way TreeMap.Update does.
+// Function fn is called holding the write lock, so it must not use the map.
+// Complexity: O(log N).
+func (s *SyncTreeMap[Key, Value]) Update(key Key, fn func(old Value, exists bool) (newValue Value, keep bool)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.m.Update(key, fn)
+}
+
+// Compute atomically sets the value for the key to the result of fn and returns it.
+// Function fn is called holding the write lock, so it must not use the map.
+// Complexity: O(log N).
+func (s *SyncTreeMap[Key, Value]) Compute(key Key, fn func(old Value, exists bool) Value) Value {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.m.Compute(key, fn)
+}
+
+// Merge atomically sets the value for the key if it doesn't exist,
+// otherwise it sets the result of fn called with the current and the given values.
+// It returns the new value.
+// Function fn is called holding the write lock, so it must not use the map.
+// Complexity: O(log N).
+func (s *SyncTreeMap[Key, Value]) Merge(key Key, value Value, fn func(old, value Value) Value) Value {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.m.Merge(key, value, fn)
+}
+
+// CompareAndSwap sets the value for the key if the value stored in a map is equal to old.
+// It reports if the value was swapped.
+// Complexity: O(log N).
+func CompareAndSwap[Key any, Value comparable](s *SyncTreeMap[Key, Value], key Key, old, value Value) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	swapped := false
+	s.m.Update(key, func(current Value, exists bool) (Value, bool) {
+		if !exists {
+			return current, false
+		}
+		if current != old {
+			return current, true
+		}
+		swapped = true
+		return value, true
+	})
+	return swapped
+}
+
+// CompareAndDelete deletes the entry for the key if its value is equal to old.
+// It reports if the entry was deleted.
+// Complexity: O(log N).
+func CompareAndDelete[Key any, Value comparable](s *SyncTreeMap[Key, Value], key Key, old Value) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	deleted := false
+	s.m.Update(key, func(current Value, exists bool) (Value, bool) {
+		deleted = exists && current == old
+		return current, exists && !deleted
+	})
+	return deleted
+}
+
+// Do calls fn with the underlying map holding the write lock.
+// Use it for compound operations that must be atomic.
+// Iterators obtained inside fn must not be used after it returns.
+func (s *SyncTreeMap[Key, Value]) Do(fn func(*TreeMap[Key, Value])) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(s.m)
+}
+
+// Clone returns a copy of a map as a plain TreeMap.
+// Complexity: O(N).
+func (s *SyncTreeMap[Key, Value]) Clone() *TreeMap[Key, Value] {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.m.Clone()
+}
+
+// Snapshot returns a read-only view of a map at the current moment.
+// Iterating over a snapshot doesn't hold any lock.
+// Complexity: O(1).
+func (s *SyncTreeMap[Key, Value]) Snapshot() *Snapshot[Key, Value] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.m.Snapshot()
+}
+
+// All returns an iterator over key-value pairs in ascending key order.
+// The read lock is held during the whole loop,
+// so the loop body must not write to the same map or it deadlocks.
+func (s *SyncTreeMap[Key, Value]) All() iter.Seq2[Key, Value] {
+	return s.locked(func(m *TreeMap[Key, Value]) iter.Seq2[Key, Value] { return m.All() })
+}
+
+// Backward returns an iterator over key-value pairs in descending key order.
+// The read lock is held during the whole loop,
+// so the loop body must not write to the same map or it deadlocks.
+func (s *SyncTreeMap[Key, Value]) Backward() iter.Seq2[Key, Value] {
+	return s.locked(func(m *TreeMap[Key, Value]) iter.Seq2[Key, Value] { return m.Backward() })
+}
+
+// Keys returns an iterator over keys in ascending order.
+// The read lock is held during the whole loop,
+// so the loop body must not write to the same map or it deadlocks.
+func (s *SyncTreeMap[Key, Value]) Keys() iter.Seq[Key] {
+	return func(yield func(Key) bool) {
+		for k := range s.All() {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns an iterator over values in ascending key order.
+// The read lock is held during the whole loop,
+// so the loop body must not write to the same map or it deadlocks.
+func (s *SyncTreeMap[Key, Value]) Values() iter.Seq[Value] {
+	return func(yield func(Value) bool) {
+		for _, v := range s.All() {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// AllRange returns an iterator over key-value pairs in the range [from, to] in ascending key order.
+// The read lock is held during the whole loop,
+// so the loop body must not write to the same map or it deadlocks.
+func (s *SyncTreeMap[Key, Value]) AllRange(from, to Key) iter.Seq2[Key, Value] {
+	return s.locked(func(m *TreeMap[Key, Value]) iter.Seq2[Key, Value] { return m.AllRange(from, to) })
+}
+
+// BackwardRange returns an iterator over key-value pairs in the range [from, to] in descending key order.
+// The read lock is held during the whole loop,
+// so the loop body must not write to the same map or it deadlocks.
+func (s *SyncTreeMap[Key, Value]) BackwardRange(from, to Key) iter.Seq2[Key, Value] {
+	return s.locked(func(m *TreeMap[Key, Value]) iter.Seq2[Key, Value] { return m.BackwardRange(from, to) })
+}
+
+// AllFrom returns an iterator over key-value pairs with keys not less than the given key in ascending order.
+// The read lock is held during the whole loop,
+// so the loop body must not write to the same map or it deadlocks.
+func (s *SyncTreeMap[Key, Value]) AllFrom(key Key) iter.Seq2[Key, Value] {
+	return s.locked(func(m *TreeMap[Key, Value]) iter.Seq2[Key, Value] { return m.AllFrom(key) })
+}
+
+// BackwardFrom returns an iterator over key-value pairs with keys not greater than the given key
+// in descending order.
+// The read lock is held during the whole loop,
+// so the loop body must not write to the same map or it deadlocks.
+func (s *SyncTreeMap[Key, Value]) BackwardFrom(key Key) iter.Seq2[Key, Value] {
+	return s.locked(func(m *TreeMap[Key, Value]) iter.Seq2[Key, Value] { return m.BackwardFrom(key) })
+}
+
+// locked wraps an iterator over the underlying map so that it runs under the read lock.
+func (s *SyncTreeMap[Key, Value]) locked(
+	seq func(*TreeMap[Key, Value]) iter.Seq2[Key, Value],
+) iter.Seq2[Key, Value] {
+	return func(yield func(Key, Value) bool) {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		seq(s.m)(yield)
+	}
+}
@@ -0,0 +1,233 @@
+package treemap
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestSyncTreeMap(t *testing.T) {
+	testSyncTreeMap(t, NewSync[int, string]())
+	testSyncTreeMap(t, NewSyncWithKeyCompare[int, string](less))
+}
+
+func TestSyncTreeMapCompound(t *testing.T) {
+	s := NewSync[int, int]()
+	if v, loaded := s.GetOrSet(1, 10); loaded || v != 10 {
+		t.Errorf("expected to set 10, got %d %v", v, loaded)
+	}
+	if v, loaded := s.GetOrSet(1, 20); !loaded || v != 10 {
+		t.Errorf("expected to load 10, got %d %v", v, loaded)
+	}
+	if CompareAndSwap(s, 1, 20, 30) {
+		t.Error("swap should fail")
+	}
+	if !CompareAndSwap(s, 1, 10, 30) {
+		t.Error("swap should succeed")
+	}
+	if CompareAndSwap(s, 2, 0, 1) || s.Contains(2) {
+		t.Error("swap of a missing key should fail")
+	}
+	if CompareAndDelete(s, 1, 10) {
+		t.Error("delete should fail")
+	}
+	if !CompareAndDelete(s, 1, 30) || s.Len() != 0 {
+		t.Error("delete should succeed")
+	}
+}
+
+func TestSyncTreeMapReadModifyWrite(t *testing.T) {
+	s := NewSync[int, int]()
+	if !s.SetIfAbsent(1, 10) || s.SetIfAbsent(1, 20) {
+		t.Error("wrong SetIfAbsent result")
+	}
+	if old, existed := s.Swap(1, 30); !existed || old != 10 {
+		t.Errorf("wrong swapped value, expected 10, got %d", old)
+	}
+	if v := s.Merge(1, 5, func(old, value int) int { return old + value }); v != 35 {
+		t.Errorf("wrong merged value, expected 35, got %d", v)
+	}
+	if v := s.Compute(2, func(old int, exists bool) int { return old + 1 }); v != 1 {
+		t.Errorf("wrong computed value, expected 1, got %d", v)
+	}
+	s.Update(2, func(int, bool) (int, bool) { return 0, false })
+	if v, ok := s.Take(1); !ok || v != 35 || s.Len() != 0 {
+		t.Errorf("wrong taken value, expected 35, got %d", v)
+	}
+	if _, ok := s.Take(1); ok {
+		t.Error("taking a missing key should fail")
+	}
+}
+
+func TestSyncTreeMapConcurrentMerge(t *testing.T) {
+	s := NewSync[int, int]()
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				s.Merge(i%10, 1, func(old, value int) int { return old + value })
+			}
+		}()
+	}
+	wg.Wait()
+	for k, v := range s.All() {
+		if v != 400 {
+			t.Errorf("wrong count of %d, expected 400, got %d", k, v)
+		}
+	}
+}
+
+func TestSyncTreeMapConcurrent(t *testing.T) {
+	s := NewSync[int, int]()
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				s.Set(w*1000+i, i)
+				if i%3 == 0 {
+					s.Del(w*1000 + i/2)
+				}
+				s.GetOrSet(-i, i)
+			}
+		}(w)
+	}
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 50; n++ {
+				prev, first := 0, true
+				for k := range s.All() {
+					if !first && k <= prev {
+						t.Error("keys are not sorted")
+						return
+					}
+					prev, first = k, false
+				}
+				snap := s.Snapshot()
+				count := 0
+				for range snap.All() {
+					count++
+				}
+				if count != snap.Len() {
+					t.Errorf("snapshot has %d elements, iterated %d", snap.Len(), count)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	m := s.Clone()
+	if m.Len() != s.Len() {
+		t.Errorf("wrong clone length, expected %d, got %d", s.Len(), m.Len())
+	}
+	if !treeInvariant(m.endNode.left) {
+		t.Error("invariant error")
+	}
+}
+
+func testSyncTreeMap(t *testing.T, s *SyncTreeMap[int, string]) {
+	for i := 0; i < 10; i++ {
+		s.Set(i, "x")
+	}
+	s.Del(0)
+	if s.Len() != 9 || s.Contains(0) {
+		t.Error("0 should be deleted")
+	}
+	if v, ok := s.Get(1); !ok || v != "x" {
+		t.Errorf("wrong value, got %s", v)
+	}
+	if k, _, _ := s.Min(); k != 1 {
+		t.Errorf("wrong min, got %d", k)
+	}
+	if k, _, _ := s.Max(); k != 9 {
+		t.Errorf("wrong max, got %d", k)
+	}
+	if k, _, ok := s.Floor(0); ok {
+		t.Errorf("floor should not exist, got %d", k)
+	}
+	if k, _, _ := s.Ceiling(0); k != 1 {
+		t.Errorf("wrong ceiling, got %d", k)
+	}
+	if k, _, _ := s.Lower(5); k != 4 {
+		t.Errorf("wrong lower, got %d", k)
+	}
+	if k, _, _ := s.Higher(5); k != 6 {
+		t.Errorf("wrong higher, got %d", k)
+	}
+	if r := s.Rank(5); r != 4 {
+		t.Errorf("wrong rank, got %d", r)
+	}
+	if k, _, ok := s.Select(2); !ok || k != 3 {
+		t.Errorf("wrong select, got %d", k)
+	}
+	if _, _, ok := s.Select(9); ok {
+		t.Error("select should be out of range")
+	}
+	var keys []int
+	for k := range s.AllRange(3, 5) {
+		keys = append(keys, k)
+	}
+	for k := range s.BackwardRange(3, 5) {
+		keys = append(keys, k)
+	}
+	for k := range s.AllFrom(8) {
+		keys = append(keys, k)
+	}
+	for k := range s.BackwardFrom(2) {
+		keys = append(keys, k)
+	}
+	exp := []int{3, 4, 5, 5, 4, 3, 8, 9, 2, 1}
+	if len(keys) != len(exp) {
+		t.Fatalf("wrong keys, expected %v, got %v", exp, keys)
+	}
+	for i := range exp {
+		if keys[i] != exp[i] {
+			t.Fatalf("wrong keys, expected %v, got %v", exp, keys)
+		}
+	}
+	count := 0
+	for range s.Keys() {
+		count++
+	}
+	for range s.Values() {
+		count++
+	}
+	for range s.Backward() {
+		break
+	}
+	if count != 18 {
+		t.Errorf("wrong number of iterated elements, expected 18, got %d", count)
+	}
+	if k, _, _ := s.PopMin(); k != 1 {
+		t.Errorf("wrong popped min, got %d", k)
+	}
+	if k, _, _ := s.PopMax(); k != 9 {
+		t.Errorf("wrong popped max, got %d", k)
+	}
+	if n := s.DelRange(3, 4); n != 2 {
+		t.Errorf("wrong number of deleted elements, got %d", n)
+	}
+	if n := s.DelBefore(3); n != 1 {
+		t.Errorf("wrong number of deleted elements, got %d", n)
+	}
+	if n := s.DelFrom(8); n != 1 {
+		t.Errorf("wrong number of deleted elements, got %d", n)
+	}
+	s.Do(func(m *TreeMap[int, string]) {
+		for it := m.Iterator(); it.Valid(); {
+			it = m.Erase(it)
+		}
+	})
+	if s.Len() != 0 {
+		t.Errorf("map should be empty, got %d elements", s.Len())
+	}
+	s.Set(1, "x")
+	s.Clear()
+	if s.Len() != 0 {
+		t.Error("map should be empty")
+	}
+}
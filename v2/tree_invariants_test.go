@@ -14,6 +14,9 @@ func treeSubInvariant[Key, Value any](x *node[Key, Value]) int {
 	if x.left == x.right && x.left != nil {
 		return 0
 	}
+	if x.size != 1+sizeOf(x.left)+sizeOf(x.right) {
+		return 0
+	}
 	if !x.isBlack {
 		if x.left != nil && !x.left.isBlack {
 			return 0
@@ -50,3 +53,69 @@ func treeInvariant[Key, Value any](root *node[Key, Value]) bool {
 	}
 	return treeSubInvariant(root) != 0
 }
+
+func persistentSubInvariant[Key, Value any](x *pnode[Key, Value]) int {
+	if x == nil {
+		return 1
+	}
+	if !x.isBlack && (isRedP(x.left) || isRedP(x.right)) {
+		return 0
+	}
+	h := persistentSubInvariant(x.left)
+	if h == 0 {
+		return 0
+	}
+	if h != persistentSubInvariant(x.right) {
+		return 0
+	}
+	if x.isBlack {
+		h++
+	}
+	return h
+}
+
+func persistentInvariant[Key, Value any](root *pnode[Key, Value]) bool {
+	if root == nil {
+		return true
+	}
+	if !root.isBlack {
+		return false
+	}
+	return persistentSubInvariant(root) != 0
+}
+
+func multisetInvariant[Key any](x *node[Key, multisetEntry]) bool {
+	if x == nil {
+		return true
+	}
+	if x.value.count <= 0 || x.value.weight != x.value.count+weightOf(x.left)+weightOf(x.right) {
+		return false
+	}
+	return multisetInvariant(x.left) && multisetInvariant(x.right)
+}
+
+func intervalInvariant[Value any](x *node[Interval[int], intervalEntry[int, Value]]) bool {
+	if x == nil {
+		return true
+	}
+	end := x.key.Hi
+	for _, c := range []*node[Interval[int], intervalEntry[int, Value]]{x.left, x.right} {
+		if c != nil && c.value.maxEnd > end {
+			end = c.value.maxEnd
+		}
+	}
+	if x.value.maxEnd != end {
+		return false
+	}
+	return intervalInvariant(x.left) && intervalInvariant(x.right)
+}
+
+func aggregateInvariant[Key, Value comparable](x *node[Key, aggregateEntry[Value]], monoid Monoid[Value]) bool {
+	if x == nil {
+		return true
+	}
+	if x.value.agg != monoid.Combine(monoid.Combine(aggOf(x.left, monoid), x.value.value), aggOf(x.right, monoid)) {
+		return false
+	}
+	return aggregateInvariant(x.left, monoid) && aggregateInvariant(x.right, monoid)
+}
@@ -1,6 +1,11 @@
 // Package treemap provides a generic key-sorted map.
 // It uses red-black tree under the hood.
 // Iterators are designed after C++.
+// Range-over-func iterators such as All and Backward are provided as well.
+// Deleting an element invalidates only the iterators pointing to that element,
+// so it is safe to delete while iterating as long as you use Erase or EraseReverse.
+// A map is not safe for concurrent use, unsynchronized writes panic like they do with the built-in map.
+// Iterators detect if they were invalidated and panic instead of returning garbage.
 //
 // Example:
 //
@@ -26,14 +31,26 @@
 //     // 1 World
 package treemap
 
-import "golang.org/x/exp/constraints"
+import (
+	"errors"
+
+	"golang.org/x/exp/constraints"
+)
 
 // TreeMap is the generic red-black tree based map
 type TreeMap[Key, Value any] struct {
 	endNode    *node[Key, Value]
 	beginNode  *node[Key, Value]
+	lastNode   *node[Key, Value]
 	count      int
 	keyCompare func(a Key, b Key) bool
+	epoch      uint64
+	augment    func(x *node[Key, Value])
+	multi      bool
+	mods       uint64
+	unchecked  bool
+	writing    bool
+	writes     uint64
 }
 
 type node[Key, Value any] struct {
@@ -41,25 +58,40 @@ type node[Key, Value any] struct {
 	left    *node[Key, Value]
 	parent  *node[Key, Value]
 	isBlack bool
+	size    int
+	epoch   uint64
 	key     Key
 	value   Value
 }
 
 // New creates and returns new TreeMap.
 func New[Key constraints.Ordered, Value any]() *TreeMap[Key, Value] {
-	endNode := &node[Key, Value]{isBlack: true}
-	return &TreeMap[Key, Value]{beginNode: endNode, endNode: endNode, keyCompare: defaultKeyCompare[Key]}
+	return newTreeMap[Key, Value](defaultKeyCompare[Key])
 }
 
 // NewWithKeyCompare creates and returns new TreeMap with the specified key compare function.
 // Parameter keyCompare is a function returning a < b.
 func NewWithKeyCompare[Key, Value any](
 	keyCompare func(a, b Key) bool,
+) *TreeMap[Key, Value] {
+	return newTreeMap[Key, Value](keyCompare)
+}
+
+func newTreeMap[Key, Value any](
+	keyCompare func(a, b Key) bool,
 ) *TreeMap[Key, Value] {
 	endNode := &node[Key, Value]{isBlack: true}
 	return &TreeMap[Key, Value]{beginNode: endNode, endNode: endNode, keyCompare: keyCompare}
 }
 
+// empty returns a new empty map sharing the key compare function and the augmentation of t
+func (t *TreeMap[Key, Value]) empty() *TreeMap[Key, Value] {
+	c := newTreeMap[Key, Value](t.keyCompare)
+	c.augment = t.augment
+	c.multi = t.multi
+	return c
+}
+
 // Len returns total count of elements in a map.
 // Complexity: O(1).
 func (t *TreeMap[Key, Value]) Len() int { return t.count }
@@ -67,9 +99,138 @@ func (t *TreeMap[Key, Value]) Len() int { return t.count }
 // Set sets the value and silently overrides previous value if it exists.
 // Complexity: O(log N).
 func (t *TreeMap[Key, Value]) Set(key Key, value Value) {
-	parent := t.endNode
+	t.startWrite()
+	defer t.endWrite()
+	t.set(key, value)
+}
+
+// Insert sets the value and silently overrides previous value if it exists.
+// It returns an iterator pointing to the element and reports if the key was inserted rather than updated.
+// Complexity: O(log N).
+func (t *TreeMap[Key, Value]) Insert(key Key, value Value) (ForwardIterator[Key, Value], bool) {
+	t.startWrite()
+	defer t.endWrite()
+	x, parent, less := t.locate(key)
+	if x != nil {
+		return t.iterator(t.setValue(x, value)), false
+	}
+	return t.iterator(t.insertNode(parent, less, key, value)), true
+}
+
+// SetIfAbsent sets the value only if the key doesn't exist and reports if it was set.
+// Complexity: O(log N).
+func (t *TreeMap[Key, Value]) SetIfAbsent(key Key, value Value) bool {
+	t.startWrite()
+	defer t.endWrite()
+	x, parent, less := t.locate(key)
+	if x != nil {
+		return false
+	}
+	t.insertNode(parent, less, key, value)
+	return true
+}
+
+// Swap sets the value and returns the previous one reporting if it existed.
+// Complexity: O(log N).
+func (t *TreeMap[Key, Value]) Swap(key Key, value Value) (old Value, existed bool) {
+	t.startWrite()
+	defer t.endWrite()
+	x, parent, less := t.locate(key)
+	if x != nil {
+		old = x.value
+		t.setValue(x, value)
+		return old, true
+	}
+	t.insertNode(parent, less, key, value)
+	return old, false
+}
+
+// Take deletes the key and returns its value reporting if it existed.
+// Complexity: O(log N).
+func (t *TreeMap[Key, Value]) Take(key Key) (Value, bool) {
+	t.startWrite()
+	defer t.endWrite()
+	z := t.findNode(key)
+	if z == nil {
+		var value Value
+		return value, false
+	}
+	value := z.value
+	t.erase(z)
+	return value, true
+}
+
+// Update inserts, modifies or deletes the value for the key in a single descent.
+// Function fn gets the current value and reports if it exists.
+// It returns the new value and reports if the key should be kept, otherwise the key is deleted.
+// Function fn may read the map but must not modify it, otherwise Update panics.
+// Complexity: O(log N).
+func (t *TreeMap[Key, Value]) Update(key Key, fn func(old Value, exists bool) (newValue Value, keep bool)) {
+	t.reading()
+	writes := t.writes
+	x, parent, less := t.locate(key)
+	var old Value
+	if x != nil {
+		old = x.value
+	}
+	value, keep := fn(old, x != nil)
+	if t.writes != writes {
+		panic("map modified by the update function")
+	}
+	t.startWrite()
+	defer t.endWrite()
+	switch {
+	case x != nil && keep:
+		t.setValue(x, value)
+	case keep:
+		t.insertNode(parent, less, key, value)
+	case x != nil:
+		t.erase(x)
+	}
+}
+
+// Compute sets the value for the key to the result of fn and returns it.
+// Function fn gets the current value and reports if it exists.
+// Function fn may read the map but must not modify it, otherwise Compute panics.
+// Complexity: O(log N).
+func (t *TreeMap[Key, Value]) Compute(key Key, fn func(old Value, exists bool) Value) Value {
+	var result Value
+	t.Update(key, func(old Value, exists bool) (Value, bool) {
+		result = fn(old, exists)
+		return result, true
+	})
+	return result
+}
+
+// Merge sets the value for the key if it doesn't exist.
+// Otherwise it sets the result of fn called with the current and the given values.
+// It returns the new value.
+// Function fn may read the map but must not modify it, otherwise Merge panics.
+// Complexity: O(log N).
+func (t *TreeMap[Key, Value]) Merge(key Key, value Value, fn func(old, value Value) Value) Value {
+	return t.Compute(key, func(old Value, exists bool) Value {
+		if !exists {
+			return value
+		}
+		return fn(old, value)
+	})
+}
+
+// set sets the value like Set does without marking the map as being modified and returns the node
+func (t *TreeMap[Key, Value]) set(key Key, value Value) *node[Key, Value] {
+	x, parent, less := t.locate(key)
+	if x != nil {
+		return t.setValue(x, value)
+	}
+	return t.insertNode(parent, less, key, value)
+}
+
+// locate returns the node with the given key if it exists.
+// Otherwise it returns nil along with the parent to insert a new node to and the side of the parent.
+func (t *TreeMap[Key, Value]) locate(key Key) (x, parent *node[Key, Value], less bool) {
+	parent = t.endNode
 	current := parent.left
-	less := true
+	less = true
 	for current != nil {
 		parent = current
 		switch {
@@ -80,11 +241,48 @@ func (t *TreeMap[Key, Value]) Set(key Key, value Value) {
 			current = current.right
 			less = false
 		default:
-			current.value = value
-			return
+			return current, nil, false
+		}
+	}
+	return nil, parent, less
+}
+
+// setValue overrides the value of x and returns x or its copy owned by the map
+func (t *TreeMap[Key, Value]) setValue(x *node[Key, Value], value Value) *node[Key, Value] {
+	x = t.own(x)
+	x.value = value
+	if t.augment != nil {
+		t.updatePath(x)
+	}
+	return x
+}
+
+// insertMulti inserts a new node even if equal keys exist, after all of them
+func (t *TreeMap[Key, Value]) insertMulti(key Key, value Value) *node[Key, Value] {
+	parent := t.endNode
+	current := parent.left
+	less := true
+	for current != nil {
+		parent = current
+		less = t.keyCompare(key, current.key)
+		if less {
+			current = current.left
+		} else {
+			current = current.right
 		}
 	}
-	x := &node[Key, Value]{parent: parent, value: value, key: key}
+	return t.insertNode(parent, less, key, value)
+}
+
+// insertNode links a new node to the parent as a left or right child and rebalances a tree
+func (t *TreeMap[Key, Value]) insertNode(
+	parent *node[Key, Value],
+	less bool,
+	key Key,
+	value Value,
+) *node[Key, Value] {
+	parent = t.own(parent)
+	x := &node[Key, Value]{parent: parent, size: 1, epoch: t.epoch, value: value, key: key}
 	if less {
 		parent.left = x
 	} else {
@@ -93,39 +291,233 @@ func (t *TreeMap[Key, Value]) Set(key Key, value Value) {
 	if t.beginNode.left != nil {
 		t.beginNode = t.beginNode.left
 	}
+	if t.lastNode == nil {
+		t.lastNode = x
+	} else if t.lastNode.right != nil {
+		t.lastNode = t.lastNode.right
+	}
+	t.updatePath(x)
 	t.insertFixup(x)
 	t.count++
+	return x
+}
+
+// SetHint sets the value like Set does and returns an iterator pointing to the element.
+// If the key goes right before or after the hint position then it takes only a couple of key comparisons,
+// otherwise it falls back to a regular descent from the root.
+// The returned iterator and the one-past-the-end iterator are good hints for the next key in ascending order.
+// Complexity: amortized O(1) key comparisons and rotations for a good hint, O(log N) in general.
+// Subtree sizes are still updated up to the root, so a good hint saves the descent but not O(log N) pointer steps.
+func (t *TreeMap[Key, Value]) SetHint(hint ForwardIterator[Key, Value], key Key, value Value) ForwardIterator[Key, Value] {
+	if hint.tree != t {
+		panic("iterator does not belong to the map")
+	}
+	hint.check()
+	t.startWrite()
+	defer t.endWrite()
+	h := hint.node
+	if h == t.endNode || t.keyCompare(key, h.key) {
+		if h == t.beginNode {
+			return t.iterator(t.insertNode(h, true, key, value))
+		}
+		var prev *node[Key, Value]
+		if h == t.endNode {
+			prev = t.lastNode
+		} else {
+			prev = predecessor(h)
+		}
+		if t.keyCompare(prev.key, key) {
+			if h.left == nil {
+				return t.iterator(t.insertNode(h, true, key, value))
+			}
+			return t.iterator(t.insertNode(prev, false, key, value))
+		}
+	} else if t.keyCompare(h.key, key) {
+		if h == t.lastNode {
+			return t.iterator(t.insertNode(h, false, key, value))
+		}
+		next := successor(h)
+		if t.keyCompare(key, next.key) {
+			if h.right == nil {
+				return t.iterator(t.insertNode(h, false, key, value))
+			}
+			return t.iterator(t.insertNode(next, true, key, value))
+		}
+	} else {
+		return t.iterator(t.setValue(h, value))
+	}
+	return t.iterator(t.set(key, value))
 }
 
 // Del deletes the value.
 // Complexity: O(log N).
 func (t *TreeMap[Key, Value]) Del(key Key) {
+	t.startWrite()
+	defer t.endWrite()
 	z := t.findNode(key)
 	if z == nil {
 		return
 	}
-	if t.beginNode == z {
-		if z.right != nil {
-			t.beginNode = z.right
-		} else {
-			t.beginNode = z.parent
-		}
+	t.erase(z)
+}
+
+// Erase deletes the element at the iterator position and returns an iterator pointing to the next element.
+// Only the iterators pointing to the deleted element are invalidated.
+// It panics if the iterator is at the one-past-the-end position.
+// Complexity: O(log N) with no key comparisons.
+func (t *TreeMap[Key, Value]) Erase(it ForwardIterator[Key, Value]) ForwardIterator[Key, Value] {
+	t.startWrite()
+	defer t.endWrite()
+	if it.tree != t {
+		panic("iterator does not belong to the map")
 	}
-	t.count--
-	removeNode(t.endNode.left, z)
+	if it.node == t.endNode {
+		panic("erasing the one-past-the-end position")
+	}
+	it.check()
+	return t.iterator(t.eraseStep(it.node, false))
+}
+
+// EraseReverse deletes the element at the reverse iterator position
+// and returns a reverse iterator pointing to the next element in reverse order.
+// Only the iterators pointing to the deleted element are invalidated.
+// It panics if the iterator is at the one-before-the-start position.
+// Complexity: O(log N) with no key comparisons.
+func (t *TreeMap[Key, Value]) EraseReverse(it ReverseIterator[Key, Value]) ReverseIterator[Key, Value] {
+	t.startWrite()
+	defer t.endWrite()
+	if it.tree != t {
+		panic("iterator does not belong to the map")
+	}
+	if it.node == nil {
+		panic("erasing the one-before-the-start position")
+	}
+	it.check()
+	return t.reverseIterator(t.eraseStep(it.node, true))
+}
+
+// EraseRange deletes the elements in the range [first, last) and returns the number of deleted elements.
+// If any elements are deleted then all the iterators of the map are invalidated
+// except the ones at the one-past-the-end position.
+// Complexity: O(log N).
+func (t *TreeMap[Key, Value]) EraseRange(first, last ForwardIterator[Key, Value]) int {
+	t.startWrite()
+	defer t.endWrite()
+	if first.tree != t || last.tree != t {
+		panic("iterator does not belong to the map")
+	}
+	first.check()
+	last.check()
+	return t.eraseRange(first.node, last.node)
+}
+
+// DelRange deletes all the keys in the range [from, to] and returns the number of deleted elements.
+// If any elements are deleted then iterators of the map are invalidated the same way EraseRange does.
+// Complexity: O(log N).
+func (t *TreeMap[Key, Value]) DelRange(from, to Key) int {
+	t.startWrite()
+	defer t.endWrite()
+	if t.keyCompare(to, from) {
+		return 0
+	}
+	return t.eraseRange(t.lowerBound(from), t.upperBound(to))
+}
+
+// DelBefore deletes all the keys that are less than the given key and returns the number of deleted elements.
+// If any elements are deleted then iterators of the map are invalidated the same way EraseRange does.
+// Complexity: O(log N).
+func (t *TreeMap[Key, Value]) DelBefore(key Key) int {
+	t.startWrite()
+	defer t.endWrite()
+	return t.eraseRange(t.beginNode, t.lowerBound(key))
+}
+
+// DelFrom deletes all the keys that are not less than the given key and returns the number of deleted elements.
+// If any elements are deleted then iterators of the map are invalidated the same way EraseRange does.
+// Complexity: O(log N).
+func (t *TreeMap[Key, Value]) DelFrom(key Key) int {
+	t.startWrite()
+	defer t.endWrite()
+	return t.eraseRange(t.lowerBound(key), t.endNode)
 }
 
 // Clear clears the map.
 // Complexity: O(1).
 func (t *TreeMap[Key, Value]) Clear() {
+	t.startWrite()
+	defer t.endWrite()
+	t.mods++
 	t.count = 0
 	t.beginNode = t.endNode
+	t.lastNode = nil
 	t.endNode.left = nil
 }
 
+// Clone returns a copy of the map.
+// The copy has exactly the same tree structure, so no key comparisons are made.
+// Values are copied by assignment.
+// Complexity: O(N).
+func (t *TreeMap[Key, Value]) Clone() *TreeMap[Key, Value] {
+	return t.CloneWith(nil)
+}
+
+// CloneWith returns a copy of the map with values copied by the specified function.
+// It is useful for values holding pointers.
+// If copyValue is nil then values are copied by assignment.
+// Complexity: O(N).
+func (t *TreeMap[Key, Value]) CloneWith(copyValue func(Value) Value) *TreeMap[Key, Value] {
+	t.reading()
+	c := t.empty()
+	c.setRoot(cloneSubtree(t.endNode.left, c.endNode, copyValue))
+	return c
+}
+
+// Min returns the least key along with its value.
+// It reports if the map is not empty.
+// Complexity: O(1).
+func (t *TreeMap[Key, Value]) Min() (Key, Value, bool) {
+	t.reading()
+	return t.entry(t.beginNode)
+}
+
+// Max returns the greatest key along with its value.
+// It reports if the map is not empty.
+// Complexity: O(1).
+func (t *TreeMap[Key, Value]) Max() (Key, Value, bool) {
+	t.reading()
+	return t.entry(t.lastNode)
+}
+
+// PopMin removes the least key from a map and returns it along with its value.
+// It reports if the map was not empty.
+// Complexity: O(log N).
+func (t *TreeMap[Key, Value]) PopMin() (Key, Value, bool) {
+	t.startWrite()
+	defer t.endWrite()
+	key, value, ok := t.entry(t.beginNode)
+	if ok {
+		t.erase(t.beginNode)
+	}
+	return key, value, ok
+}
+
+// PopMax removes the greatest key from a map and returns it along with its value.
+// It reports if the map was not empty.
+// Complexity: O(log N).
+func (t *TreeMap[Key, Value]) PopMax() (Key, Value, bool) {
+	t.startWrite()
+	defer t.endWrite()
+	key, value, ok := t.entry(t.lastNode)
+	if ok {
+		t.erase(t.lastNode)
+	}
+	return key, value, ok
+}
+
 // Get retrieves a value from a map for specified key and reports if it exists.
 // Complexity: O(log N).
 func (t *TreeMap[Key, Value]) Get(id Key) (Value, bool) {
+	t.reading()
 	node := t.findNode(id)
 	if node == nil {
 		node = t.endNode
@@ -135,7 +527,10 @@ func (t *TreeMap[Key, Value]) Get(id Key) (Value, bool) {
 
 // Contains checks if key exists in a map.
 // Complexity: O(log N)
-func (t *TreeMap[Key, Value]) Contains(id Key) bool { return t.findNode(id) != nil }
+func (t *TreeMap[Key, Value]) Contains(id Key) bool {
+	t.reading()
+	return t.findNode(id) != nil
+}
 
 // Range returns a pair of iterators that you can use to go through all the keys in the range [from, to].
 // More specifically it returns iterators pointing to lower bound and upper bound.
@@ -147,73 +542,209 @@ func (t *TreeMap[Key, Value]) Range(from, to Key) (ForwardIterator[Key, Value],
 // LowerBound returns an iterator pointing to the first element that is not less than the given key.
 // Complexity: O(log N).
 func (t *TreeMap[Key, Value]) LowerBound(key Key) ForwardIterator[Key, Value] {
-	result := t.endNode
-	node := t.endNode.left
-	if node == nil {
-		return ForwardIterator[Key, Value]{tree: t, node: t.endNode}
-	}
-	for {
-		if t.keyCompare(node.key, key) {
-			if node.right != nil {
-				node = node.right
-			} else {
-				return ForwardIterator[Key, Value]{tree: t, node: result}
-			}
-		} else {
-			result = node
-			if node.left != nil {
-				node = node.left
-			} else {
-				return ForwardIterator[Key, Value]{tree: t, node: result}
-			}
-		}
-	}
+	t.reading()
+	return t.iterator(t.lowerBound(key))
 }
 
 // UpperBound returns an iterator pointing to the first element that is greater than the given key.
 // Complexity: O(log N).
 func (t *TreeMap[Key, Value]) UpperBound(key Key) ForwardIterator[Key, Value] {
-	result := t.endNode
-	node := t.endNode.left
-	if node == nil {
-		return ForwardIterator[Key, Value]{tree: t, node: t.endNode}
+	t.reading()
+	return t.iterator(t.upperBound(key))
+}
+
+// Floor returns the greatest key that is less than or equal to the given key along with its value.
+// It reports if such key exists.
+// Complexity: O(log N).
+func (t *TreeMap[Key, Value]) Floor(key Key) (Key, Value, bool) {
+	t.reading()
+	return t.entry(t.floorNode(key))
+}
+
+// Ceiling returns the least key that is greater than or equal to the given key along with its value.
+// It reports if such key exists.
+// Complexity: O(log N).
+func (t *TreeMap[Key, Value]) Ceiling(key Key) (Key, Value, bool) {
+	t.reading()
+	return t.entry(t.lowerBound(key))
+}
+
+// Lower returns the greatest key that is strictly less than the given key along with its value.
+// It reports if such key exists.
+// Complexity: O(log N).
+func (t *TreeMap[Key, Value]) Lower(key Key) (Key, Value, bool) {
+	t.reading()
+	return t.entry(t.lowerNode(key))
+}
+
+// Higher returns the least key that is strictly greater than the given key along with its value.
+// It reports if such key exists.
+// Complexity: O(log N).
+func (t *TreeMap[Key, Value]) Higher(key Key) (Key, Value, bool) {
+	t.reading()
+	return t.entry(t.upperBound(key))
+}
+
+// FloorIterator returns an iterator pointing to the greatest element that is less than or equal to the given key.
+// It returns the one-past-the-end iterator if there is no such element.
+// Use ForwardIterator.Reverse to scan the map backwards from this position.
+// Complexity: O(log N).
+func (t *TreeMap[Key, Value]) FloorIterator(key Key) ForwardIterator[Key, Value] {
+	t.reading()
+	return t.iteratorOrEnd(t.floorNode(key))
+}
+
+// CeilingIterator returns an iterator pointing to the least element that is greater than or equal to the given key.
+// It returns the one-past-the-end iterator if there is no such element.
+// It is the same as LowerBound.
+// Complexity: O(log N).
+func (t *TreeMap[Key, Value]) CeilingIterator(key Key) ForwardIterator[Key, Value] {
+	return t.LowerBound(key)
+}
+
+// LowerIterator returns an iterator pointing to the greatest element that is strictly less than the given key.
+// It returns the one-past-the-end iterator if there is no such element.
+// Use ForwardIterator.Reverse to scan the map backwards from this position.
+// Complexity: O(log N).
+func (t *TreeMap[Key, Value]) LowerIterator(key Key) ForwardIterator[Key, Value] {
+	t.reading()
+	return t.iteratorOrEnd(t.lowerNode(key))
+}
+
+// HigherIterator returns an iterator pointing to the least element that is strictly greater than the given key.
+// It returns the one-past-the-end iterator if there is no such element.
+// It is the same as UpperBound.
+// Complexity: O(log N).
+func (t *TreeMap[Key, Value]) HigherIterator(key Key) ForwardIterator[Key, Value] {
+	return t.UpperBound(key)
+}
+
+// Select returns an iterator pointing to the k-th smallest element, counting from zero.
+// It returns the one-past-the-end iterator if k is out of range.
+// Complexity: O(log N).
+func (t *TreeMap[Key, Value]) Select(k int) ForwardIterator[Key, Value] {
+	t.reading()
+	return t.iterator(t.selectNode(k))
+}
+
+// selectNode returns the node at the given position or the end node if there is no such position
+func (t *TreeMap[Key, Value]) selectNode(k int) *node[Key, Value] {
+	if k < 0 || k >= t.count {
+		return t.endNode
 	}
+	node := t.endNode.left
 	for {
-		if !t.keyCompare(key, node.key) {
-			if node.right != nil {
-				node = node.right
-			} else {
-				return ForwardIterator[Key, Value]{tree: t, node: result}
-			}
-		} else {
-			result = node
-			if node.left != nil {
-				node = node.left
-			} else {
-				return ForwardIterator[Key, Value]{tree: t, node: result}
-			}
+		left := sizeOf(node.left)
+		switch {
+		case k < left:
+			node = node.left
+		case k > left:
+			k -= left + 1
+			node = node.right
+		default:
+			return node
 		}
 	}
 }
 
+// Rank returns the number of keys that are less than the given key.
+// Complexity: O(log N).
+func (t *TreeMap[Key, Value]) Rank(key Key) int {
+	t.reading()
+	return t.rank(key, false)
+}
+
+// Bounds tells which ends of a key range are included.
+type Bounds int
+
+const (
+	// BoundsClosed range [from, to] includes both ends
+	BoundsClosed Bounds = iota
+	// BoundsOpen range (from, to) excludes both ends
+	BoundsOpen
+	// BoundsClosedOpen range [from, to) includes only from
+	BoundsClosedOpen
+	// BoundsOpenClosed range (from, to] includes only to
+	BoundsOpenClosed
+)
+
+// CountRange returns the number of keys in the range between from and to.
+// Parameter bounds tells if from and to themselves are counted.
+// Complexity: O(log N).
+func (t *TreeMap[Key, Value]) CountRange(from, to Key, bounds Bounds) int {
+	t.reading()
+	below := t.rank(from, bounds == BoundsOpen || bounds == BoundsOpenClosed)
+	upTo := t.rank(to, bounds == BoundsClosed || bounds == BoundsOpenClosed)
+	if upTo < below {
+		return 0
+	}
+	return upTo - below
+}
+
+// Distance returns the number of elements in the range [first, last).
+// It is negative if last goes before first.
+// Complexity: O(log N).
+func (t *TreeMap[Key, Value]) Distance(first, last ForwardIterator[Key, Value]) int {
+	t.reading()
+	if first.tree != t || last.tree != t {
+		panic("iterator does not belong to the map")
+	}
+	first.check()
+	last.check()
+	return t.index(last.node) - t.index(first.node)
+}
+
 // Iterator returns an iterator for tree map.
 // It starts at the first element and goes to the one-past-the-end position.
 // You can iterate a map at O(N) complexity.
 // Method complexity: O(1)
 func (t *TreeMap[Key, Value]) Iterator() ForwardIterator[Key, Value] {
-	return ForwardIterator[Key, Value]{tree: t, node: t.beginNode}
+	t.reading()
+	return t.iterator(t.beginNode)
+}
+
+// End returns an iterator pointing to the one-past-the-end position.
+// Complexity: O(1)
+func (t *TreeMap[Key, Value]) End() ForwardIterator[Key, Value] {
+	return t.iterator(t.endNode)
 }
 
 // Reverse returns a reverse iterator for tree map.
 // It starts at the last element and goes to the one-before-the-start position.
 // You can iterate a map at O(N) complexity.
-// Method complexity: O(log N)
+// Method complexity: O(1)
 func (t *TreeMap[Key, Value]) Reverse() ReverseIterator[Key, Value] {
-	node := t.endNode.left
-	if node != nil {
-		node = mostRight(node)
+	t.reading()
+	return t.reverseIterator(t.lastNode)
+}
+
+// SetIteratorChecks turns the detection of invalidated iterators on or off.
+// If it is on, which is the default, then using an invalidated iterator panics.
+// Turn it off to save a couple of comparisons per iterator call in hot paths.
+func (t *TreeMap[Key, Value]) SetIteratorChecks(enabled bool) { t.unchecked = !enabled }
+
+// startWrite marks the map as being modified.
+// Like the built-in map, it is a cheap best-effort detection of unsynchronized concurrent use.
+func (t *TreeMap[Key, Value]) startWrite() {
+	if t.writing {
+		panic("concurrent map writes")
+	}
+	t.writing = true
+}
+
+func (t *TreeMap[Key, Value]) endWrite() {
+	if !t.writing {
+		panic("concurrent map writes")
+	}
+	t.writing = false
+	t.writes++
+}
+
+// reading panics if the map is being modified
+func (t *TreeMap[Key, Value]) reading() {
+	if t.writing {
+		panic("concurrent map read and map write")
 	}
-	return ReverseIterator[Key, Value]{tree: t, node: node}
 }
 
 func defaultKeyCompare[Key constraints.Ordered](
@@ -237,6 +768,245 @@ func (t *TreeMap[Key, Value]) findNode(id Key) *node[Key, Value] {
 	return nil
 }
 
+func sizeOf[Key, Value any](
+	x *node[Key, Value],
+) int {
+	if x == nil {
+		return 0
+	}
+	return x.size
+}
+
+// rank returns the number of keys that are less than the given key,
+// the key itself is counted as well if inclusive is set
+func (t *TreeMap[Key, Value]) rank(key Key, inclusive bool) int {
+	rank := 0
+	node := t.endNode.left
+	for node != nil {
+		if t.keyCompare(node.key, key) || inclusive && !t.keyCompare(key, node.key) {
+			rank += sizeOf(node.left) + 1
+			node = node.right
+		} else {
+			node = node.left
+		}
+	}
+	return rank
+}
+
+// index returns the number of nodes before x, the end node gets the number of all the nodes
+func (t *TreeMap[Key, Value]) index(x *node[Key, Value]) int {
+	if x == t.endNode {
+		return t.count
+	}
+	i := sizeOf(x.left)
+	for ; x.parent != t.endNode; x = x.parent {
+		if x == x.parent.right {
+			i += sizeOf(x.parent.left) + 1
+		}
+	}
+	return i
+}
+
+// update recalculates the size of x and its augmented data from its children
+func (t *TreeMap[Key, Value]) update(x *node[Key, Value]) {
+	x.size = 1 + sizeOf(x.left) + sizeOf(x.right)
+	if t.augment != nil {
+		t.augment(x)
+	}
+}
+
+// updatePath recalculates the nodes from x up to the root, they must be owned by the map
+func (t *TreeMap[Key, Value]) updatePath(x *node[Key, Value]) {
+	for ; x != t.endNode; x = x.parent {
+		t.update(x)
+	}
+}
+
+func cloneSubtree[Key, Value any](
+	x *node[Key, Value],
+	parent *node[Key, Value],
+	copyValue func(Value) Value,
+) *node[Key, Value] {
+	if x == nil {
+		return nil
+	}
+	y := &node[Key, Value]{parent: parent, isBlack: x.isBlack, size: x.size, key: x.key, value: x.value}
+	if copyValue != nil {
+		y.value = copyValue(x.value)
+	}
+	y.left = cloneSubtree(x.left, y, copyValue)
+	y.right = cloneSubtree(x.right, y, copyValue)
+	return y
+}
+
+// erase removes the node from a tree keeping the cached first and last nodes up to date
+// eraseStep deletes x and returns its successor, or its predecessor if backward is set
+func (t *TreeMap[Key, Value]) eraseStep(x *node[Key, Value], backward bool) *node[Key, Value] {
+	z := t.own(x)
+	var next *node[Key, Value]
+	if backward {
+		next = predecessor(z)
+		if next != nil {
+			next = t.own(next)
+		}
+	} else {
+		next = successor(z)
+		if next != t.endNode {
+			next = t.own(next)
+		}
+	}
+	t.erase(z)
+	x.size = 0 // x may be shared with a snapshot, see erase
+	return next
+}
+
+func (t *TreeMap[Key, Value]) erase(z *node[Key, Value]) {
+	shared := z
+	z = t.own(z)
+	if t.beginNode == z {
+		if z.right != nil {
+			t.beginNode = z.right
+		} else {
+			t.beginNode = z.parent
+		}
+	}
+	if t.lastNode == z {
+		t.lastNode = predecessor(z)
+	}
+	t.count--
+	t.removeNode(t.endNode.left, z)
+	z.size = 0
+	// The node may have been copied from the one shared with a snapshot.
+	// Snapshots never read sizes, so the shared node is marked as well for the loops holding it.
+	shared.size = 0
+}
+
+// mutable returns the node itself if it is owned by the map or its private copy if it is shared with a snapshot.
+// The copy is not linked to the parent, callers take care of it.
+func (t *TreeMap[Key, Value]) mutable(x *node[Key, Value]) *node[Key, Value] {
+	if x == nil || x.epoch == t.epoch {
+		return x
+	}
+	c := *x
+	y := &c
+	y.epoch = t.epoch
+	t.mods++
+	if y.left != nil {
+		y.left.parent = y
+	}
+	if y.right != nil {
+		y.right.parent = y
+	}
+	return y
+}
+
+// own returns the node itself if it is owned by the map or its private copy if it is shared with a snapshot.
+// The copy takes the place of the node in the tree, so the path from the root gets copied as well.
+// Nodes without a parent such as the end node are always owned.
+// Parent links are never read by snapshots, so they are updated in shared nodes too.
+func (t *TreeMap[Key, Value]) own(x *node[Key, Value]) *node[Key, Value] {
+	if x.parent == nil || x.epoch == t.epoch {
+		return x
+	}
+	parent := t.own(x.parent)
+	y := t.mutable(x)
+	y.parent = parent
+	if parent.left == x {
+		parent.left = y
+	} else {
+		parent.right = y
+	}
+	if t.beginNode == x {
+		t.beginNode = y
+	}
+	if t.lastNode == x {
+		t.lastNode = y
+	}
+	return y
+}
+
+// lowerBound returns the first node with a key not less than the given one or the end node
+func (t *TreeMap[Key, Value]) lowerBound(key Key) *node[Key, Value] {
+	result := t.endNode
+	x := t.endNode.left
+	for x != nil {
+		if t.keyCompare(x.key, key) {
+			x = x.right
+		} else {
+			result = x
+			x = x.left
+		}
+	}
+	return result
+}
+
+// upperBound returns the first node with a key greater than the given one or the end node
+func (t *TreeMap[Key, Value]) upperBound(key Key) *node[Key, Value] {
+	result := t.endNode
+	x := t.endNode.left
+	for x != nil {
+		if t.keyCompare(key, x.key) {
+			result = x
+			x = x.left
+		} else {
+			x = x.right
+		}
+	}
+	return result
+}
+
+// floorNode returns the last node with a key not greater than the given one or nil
+func (t *TreeMap[Key, Value]) floorNode(key Key) *node[Key, Value] {
+	upper := t.upperBound(key)
+	if upper == t.beginNode {
+		return nil
+	}
+	return predecessor(upper)
+}
+
+// lowerNode returns the last node with a key less than the given one or nil
+func (t *TreeMap[Key, Value]) lowerNode(key Key) *node[Key, Value] {
+	lower := t.lowerBound(key)
+	if lower == t.beginNode {
+		return nil
+	}
+	return predecessor(lower)
+}
+
+func (t *TreeMap[Key, Value]) entry(x *node[Key, Value]) (Key, Value, bool) {
+	if x == nil || x == t.endNode {
+		var key Key
+		var value Value
+		return key, value, false
+	}
+	return x.key, x.value, true
+}
+
+func (t *TreeMap[Key, Value]) iteratorOrEnd(x *node[Key, Value]) ForwardIterator[Key, Value] {
+	if x == nil {
+		x = t.endNode
+	}
+	return t.iterator(x)
+}
+
+// iterator returns an iterator pointing to x.
+// Iterators at the one-past-the-end position never get invalidated, so they don't record modifications.
+func (t *TreeMap[Key, Value]) iterator(x *node[Key, Value]) ForwardIterator[Key, Value] {
+	if x == t.endNode {
+		return ForwardIterator[Key, Value]{tree: t, node: x}
+	}
+	return ForwardIterator[Key, Value]{tree: t, node: x, mods: t.mods}
+}
+
+// reverseIterator returns a reverse iterator pointing to x.
+// Iterators at the one-before-the-start position never get invalidated, so they don't record modifications.
+func (t *TreeMap[Key, Value]) reverseIterator(x *node[Key, Value]) ReverseIterator[Key, Value] {
+	if x == nil {
+		return ReverseIterator[Key, Value]{tree: t}
+	}
+	return ReverseIterator[Key, Value]{tree: t, node: x, mods: t.mods}
+}
+
 func mostLeft[Key, Value any](
 	x *node[Key, Value],
 ) *node[Key, Value] {
@@ -279,9 +1049,7 @@ func predecessor[Key, Value any](
 	return x.parent
 }
 
-func rotateLeft[Key, Value any](
-	x *node[Key, Value],
-) {
+func (t *TreeMap[Key, Value]) rotateLeft(x *node[Key, Value]) {
 	y := x.right
 	x.right = y.left
 	if x.right != nil {
@@ -295,11 +1063,11 @@ func rotateLeft[Key, Value any](
 	}
 	y.left = x
 	x.parent = y
+	t.update(x)
+	t.update(y)
 }
 
-func rotateRight[Key, Value any](
-	x *node[Key, Value],
-) {
+func (t *TreeMap[Key, Value]) rotateRight(x *node[Key, Value]) {
 	y := x.left
 	x.left = y.right
 	if x.left != nil {
@@ -313,6 +1081,8 @@ func rotateRight[Key, Value any](
 	}
 	y.right = x
 	x.parent = y
+	t.update(x)
+	t.update(y)
 }
 
 func (t *TreeMap[Key, Value]) insertFixup(x *node[Key, Value]) {
@@ -326,17 +1096,17 @@ func (t *TreeMap[Key, Value]) insertFixup(x *node[Key, Value]) {
 				x.isBlack = true
 				x = x.parent
 				x.isBlack = x == root
-				y.isBlack = true
+				t.own(y).isBlack = true
 			} else {
 				if x != x.parent.left {
 					x = x.parent
-					rotateLeft(x)
+					t.rotateLeft(x)
 				}
 				x = x.parent
 				x.isBlack = true
 				x = x.parent
 				x.isBlack = false
-				rotateRight(x)
+				t.rotateRight(x)
 				break
 			}
 		} else {
@@ -346,33 +1116,37 @@ func (t *TreeMap[Key, Value]) insertFixup(x *node[Key, Value]) {
 				x.isBlack = true
 				x = x.parent
 				x.isBlack = x == root
-				y.isBlack = true
+				t.own(y).isBlack = true
 			} else {
 				if x == x.parent.left {
 					x = x.parent
-					rotateRight(x)
+					t.rotateRight(x)
 				}
 				x = x.parent
 				x.isBlack = true
 				x = x.parent
 				x.isBlack = false
-				rotateLeft(x)
+				t.rotateLeft(x)
 				break
 			}
 		}
 	}
 }
 
+// removeNode removes z from a tree.
+// Node z must be owned by the map.
+//
 //nolint:gocyclo
 //noinspection GoNilness
-func removeNode[Key, Value any](
+func (t *TreeMap[Key, Value]) removeNode(
 	root, z *node[Key, Value],
 ) {
+	endNode := root.parent
 	var y *node[Key, Value]
 	if z.left == nil || z.right == nil {
 		y = z
 	} else {
-		y = successor(z)
+		y = t.own(successor(z))
 	}
 	var x *node[Key, Value]
 	if y.left != nil {
@@ -396,6 +1170,10 @@ func removeNode[Key, Value any](
 		w = y.parent.left
 	}
 	removedBlack := y.isBlack
+	fix := y.parent
+	if fix == z {
+		fix = y
+	}
 	if y != z {
 		y.parent = z.parent
 		if z == z.parent.left {
@@ -414,20 +1192,24 @@ func removeNode[Key, Value any](
 			root = y
 		}
 	}
+	for ; fix != endNode; fix = fix.parent {
+		t.update(fix)
+	}
 	if removedBlack && root != nil {
 		if x != nil {
-			x.isBlack = true
+			t.own(x).isBlack = true
 		} else {
 			for {
+				w = t.own(w)
 				if w != w.parent.left {
 					if !w.isBlack {
 						w.isBlack = true
 						w.parent.isBlack = false
-						rotateLeft(w.parent)
+						t.rotateLeft(w.parent)
 						if root == w.left {
 							root = w
 						}
-						w = w.left.right
+						w = t.own(w.left.right)
 					}
 					if (w.left == nil || w.left.isBlack) && (w.right == nil || w.right.isBlack) {
 						w.isBlack = false
@@ -443,26 +1225,26 @@ func removeNode[Key, Value any](
 						}
 					} else {
 						if w.right == nil || w.right.isBlack {
-							w.left.isBlack = true
+							t.own(w.left).isBlack = true
 							w.isBlack = false
-							rotateRight(w)
+							t.rotateRight(w)
 							w = w.parent
 						}
 						w.isBlack = w.parent.isBlack
 						w.parent.isBlack = true
-						w.right.isBlack = true
-						rotateLeft(w.parent)
+						t.own(w.right).isBlack = true
+						t.rotateLeft(w.parent)
 						break
 					}
 				} else {
 					if !w.isBlack {
 						w.isBlack = true
 						w.parent.isBlack = false
-						rotateRight(w.parent)
+						t.rotateRight(w.parent)
 						if root == w.right {
 							root = w
 						}
-						w = w.right.left
+						w = t.own(w.right.left)
 					}
 					if (w.left == nil || w.left.isBlack) && (w.right == nil || w.right.isBlack) {
 						w.isBlack = false
@@ -478,15 +1260,15 @@ func removeNode[Key, Value any](
 						}
 					} else {
 						if w.left == nil || w.left.isBlack {
-							w.right.isBlack = true
+							t.own(w.right).isBlack = true
 							w.isBlack = false
-							rotateLeft(w)
+							t.rotateLeft(w)
 							w = w.parent
 						}
 						w.isBlack = w.parent.isBlack
 						w.parent.isBlack = true
-						w.left.isBlack = true
-						rotateRight(w.parent)
+						t.own(w.left).isBlack = true
+						t.rotateRight(w.parent)
 						break
 					}
 				}
@@ -495,12 +1277,18 @@ func removeNode[Key, Value any](
 	}
 }
 
+// ErrInvalidIterator is reported by iterators used after their element was deleted,
+// after the map was cleared, split or joined, after a range deletion,
+// or after the first modification following a snapshot.
+var ErrInvalidIterator = errors.New("treemap: iterator used after the map was modified")
+
 // ForwardIterator represents a position in a tree map.
 // It is designed to iterate a map in a forward order.
 // It can point to any position from the first element to the one-past-the-end element.
 type ForwardIterator[Key, Value any] struct {
 	tree *TreeMap[Key, Value]
 	node *node[Key, Value]
+	mods uint64
 }
 
 // Valid reports if the iterator position is valid.
@@ -513,23 +1301,58 @@ func (i *ForwardIterator[Key, Value]) Next() {
 	if i.node == i.tree.endNode {
 		panic("out of bound iteration")
 	}
-	i.node = successor(i.node)
+	i.check()
+	i.tree.reading()
+	*i = i.tree.iterator(successor(i.node))
 }
 
 // Prev moves an iterator to the previous element.
 // It panics if it goes out of bounds.
 func (i *ForwardIterator[Key, Value]) Prev() {
-	i.node = predecessor(i.node)
-	if i.node == nil {
+	i.check()
+	i.tree.reading()
+	x := predecessor(i.node)
+	if x == nil {
 		panic("out of bound iteration")
 	}
+	*i = i.tree.iterator(x)
+}
+
+// Reverse returns a reverse iterator pointing to the same element.
+// If the iterator is at the one-past-the-end position then the result is at the one-before-the-start position.
+func (i ForwardIterator[Key, Value]) Reverse() ReverseIterator[Key, Value] {
+	if i.node == i.tree.endNode {
+		return ReverseIterator[Key, Value]{tree: i.tree}
+	}
+	return ReverseIterator[Key, Value]{tree: i.tree, node: i.node, mods: i.mods}
 }
 
 // Key returns a key at the iterator position
-func (i ForwardIterator[Key, Value]) Key() Key { return i.node.key }
+func (i ForwardIterator[Key, Value]) Key() Key {
+	i.check()
+	return i.node.key
+}
 
 // Value returns a value at the iterator position
-func (i ForwardIterator[Key, Value]) Value() Value { return i.node.value }
+func (i ForwardIterator[Key, Value]) Value() Value {
+	i.check()
+	return i.node.value
+}
+
+// Err returns ErrInvalidIterator if the iterator was invalidated by a modification of the map and nil otherwise.
+// The one-past-the-end position is never invalidated.
+func (i ForwardIterator[Key, Value]) Err() error {
+	if i.node != i.tree.endNode && (i.mods != i.tree.mods || i.node.size == 0) {
+		return ErrInvalidIterator
+	}
+	return nil
+}
+
+func (i ForwardIterator[Key, Value]) check() {
+	if !i.tree.unchecked && i.Err() != nil {
+		panic("iterator used after the map was modified")
+	}
+}
 
 // ReverseIterator represents a position in a tree map.
 // It is designed to iterate a map in a reverse order.
@@ -537,6 +1360,7 @@ func (i ForwardIterator[Key, Value]) Value() Value { return i.node.value }
 type ReverseIterator[Key, Value any] struct {
 	tree *TreeMap[Key, Value]
 	node *node[Key, Value]
+	mods uint64
 }
 
 // Valid reports if the iterator position is valid.
@@ -549,24 +1373,58 @@ func (i *ReverseIterator[Key, Value]) Next() {
 	if i.node == nil {
 		panic("out of bound iteration")
 	}
-	i.node = predecessor(i.node)
+	i.check()
+	i.tree.reading()
+	*i = i.tree.reverseIterator(predecessor(i.node))
 }
 
 // Prev moves an iterator to the previous element in reverse order.
 // It panics if it goes out of bounds.
 func (i *ReverseIterator[Key, Value]) Prev() {
+	i.check()
+	i.tree.reading()
+	x := i.tree.beginNode
 	if i.node != nil {
-		i.node = successor(i.node)
-	} else {
-		i.node = i.tree.beginNode
+		x = successor(i.node)
 	}
-	if i.node == i.tree.endNode {
+	if x == i.tree.endNode {
 		panic("out of bound iteration")
 	}
+	*i = i.tree.reverseIterator(x)
+}
+
+// Forward returns a forward iterator pointing to the same element.
+// If the iterator is at the one-before-the-start position then the result is at the one-past-the-end position.
+func (i ReverseIterator[Key, Value]) Forward() ForwardIterator[Key, Value] {
+	if i.node == nil {
+		return ForwardIterator[Key, Value]{tree: i.tree, node: i.tree.endNode}
+	}
+	return ForwardIterator[Key, Value]{tree: i.tree, node: i.node, mods: i.mods}
 }
 
 // Key returns a key at the iterator position
-func (i ReverseIterator[Key, Value]) Key() Key { return i.node.key }
+func (i ReverseIterator[Key, Value]) Key() Key {
+	i.check()
+	return i.node.key
+}
 
 // Value returns a value at the iterator position
-func (i ReverseIterator[Key, Value]) Value() Value { return i.node.value }
+func (i ReverseIterator[Key, Value]) Value() Value {
+	i.check()
+	return i.node.value
+}
+
+// Err returns ErrInvalidIterator if the iterator was invalidated by a modification of the map and nil otherwise.
+// The one-before-the-start position is never invalidated.
+func (i ReverseIterator[Key, Value]) Err() error {
+	if i.node != nil && (i.mods != i.tree.mods || i.node.size == 0) {
+		return ErrInvalidIterator
+	}
+	return nil
+}
+
+func (i ReverseIterator[Key, Value]) check() {
+	if !i.tree.unchecked && i.Err() != nil {
+		panic("iterator used after the map was modified")
+	}
+}
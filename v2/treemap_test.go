@@ -1,6 +1,9 @@
 package treemap
 
 import (
+	"errors"
+	"maps"
+	"slices"
 	"testing"
 )
 
@@ -96,6 +99,348 @@ func TestRangeSingle(t *testing.T) {
 	testRangeSingle(t, NewWithKeyCompare[int, string](less))
 }
 
+func TestSelect(t *testing.T) {
+	testSelect(t, New[int, string]())
+	testSelect(t, NewWithKeyCompare[int, string](less))
+}
+
+func TestRank(t *testing.T) {
+	testRank(t, New[int, string]())
+	testRank(t, NewWithKeyCompare[int, string](less))
+}
+
+func TestCountRange(t *testing.T) {
+	testCountRange(t, New[int, string]())
+	testCountRange(t, NewWithKeyCompare[int, string](less))
+}
+
+func TestDistance(t *testing.T) {
+	testDistance(t, New[int, string]())
+	testDistance(t, NewWithKeyCompare[int, string](less))
+}
+
+func TestInvalidIterators(t *testing.T) {
+	testInvalidIterators(t, New[int, string]())
+	testInvalidIterators(t, NewWithKeyCompare[int, string](less))
+}
+
+func TestWritingDetection(t *testing.T) {
+	var tr *TreeMap[int, string]
+	var inner func()
+	tr = NewWithKeyCompare[int, string](func(a, b int) bool {
+		if inner != nil {
+			f := inner
+			inner = nil
+			f()
+		}
+		return a < b
+	})
+	tr.Set(1, "x")
+	tests := []struct {
+		name string
+		f    func()
+		exp  string
+	}{
+		{"Get", func() { tr.Get(1) }, "concurrent map read and map write"},
+		{"Iterator", func() { tr.Iterator() }, "concurrent map read and map write"},
+		{"All", func() {
+			for range tr.All() {
+			}
+		}, "concurrent map read and map write"},
+		{"Set", func() { tr.Set(3, "z") }, "concurrent map writes"},
+		{"Del", func() { tr.Del(1) }, "concurrent map writes"},
+	}
+	for _, test := range tests {
+		func() {
+			defer func() {
+				if r := recover(); r != test.exp {
+					t.Errorf("%s during Set should panic with %q, got %v", test.name, test.exp, r)
+				}
+			}()
+			inner = test.f
+			tr.Set(2, "y")
+		}()
+	}
+	it := tr.Iterator()
+	tr.writing = true
+	func() {
+		defer func() {
+			if r := recover(); r != "concurrent map read and map write" {
+				t.Errorf("Next during a write should panic, got %v", r)
+			}
+		}()
+		it.Next()
+	}()
+	tr.writing = false
+	tr.Set(3, "z")
+	if !tr.Contains(3) {
+		t.Error("map should be usable after a write")
+	}
+}
+
+func TestSetHint(t *testing.T) {
+	testSetHint(t, New[int, string]())
+	testSetHint(t, NewWithKeyCompare[int, string](less))
+}
+
+func TestSetHintComparisons(t *testing.T) {
+	comparisons := 0
+	tr := NewWithKeyCompare[int, int](func(a, b int) bool {
+		comparisons++
+		return a < b
+	})
+	hint := tr.End()
+	for i := 0; i < 1000; i++ {
+		hint = tr.SetHint(hint, i, i)
+		hint.Next()
+	}
+	if comparisons > 2000 {
+		t.Errorf("too many comparisons for appending with hints, got %d", comparisons)
+	}
+	comparisons = 0
+	hint = tr.Reverse().Forward()
+	for i := 1000; i < 2000; i++ {
+		hint = tr.SetHint(hint, i, i)
+	}
+	if comparisons > 2000 {
+		t.Errorf("too many comparisons for appending after the returned hints, got %d", comparisons)
+	}
+	comparisons = 0
+	hint = tr.Iterator()
+	for i := -1; i >= -1000; i-- {
+		hint = tr.SetHint(hint, i, i)
+	}
+	if comparisons > 2000 {
+		t.Errorf("too many comparisons for prepending with hints, got %d", comparisons)
+	}
+	if !treeInvariant(tr.endNode.left) {
+		t.Error("invariant error")
+	}
+}
+
+func TestInsert(t *testing.T) {
+	testInsert(t, New[int, string]())
+	testInsert(t, NewWithKeyCompare[int, string](less))
+}
+
+func TestSwapTake(t *testing.T) {
+	testSwapTake(t, New[int, string]())
+	testSwapTake(t, NewWithKeyCompare[int, string](less))
+}
+
+func TestUpdate(t *testing.T) {
+	testUpdate(t, New[int, string]())
+	testUpdate(t, NewWithKeyCompare[int, string](less))
+}
+
+func TestComputeMerge(t *testing.T) {
+	tr := New[string, int]()
+	for _, w := range []string{"a", "b", "a", "c", "a"} {
+		tr.Merge(w, 1, func(old, value int) int { return old + value })
+	}
+	if v, _ := tr.Get("a"); v != 3 {
+		t.Errorf("wrong merged value, expected 3, got %d", v)
+	}
+	if v := tr.Compute("b", func(old int, exists bool) int { return old * 10 }); v != 10 {
+		t.Errorf("wrong computed value, expected 10, got %d", v)
+	}
+	if v := tr.Compute("d", func(old int, exists bool) int {
+		if exists {
+			t.Error("d should not exist")
+		}
+		return 7
+	}); v != 7 || !tr.Contains("d") {
+		t.Errorf("wrong computed value, expected 7, got %d", v)
+	}
+}
+
+func TestFloorCeiling(t *testing.T) {
+	testFloorCeiling(t, New[int, string]())
+	testFloorCeiling(t, NewWithKeyCompare[int, string](less))
+}
+
+func TestNeighborIterators(t *testing.T) {
+	testNeighborIterators(t, New[int, string]())
+	testNeighborIterators(t, NewWithKeyCompare[int, string](less))
+}
+
+func TestMinMax(t *testing.T) {
+	testMinMaxEntries(t, New[int, string]())
+	testMinMaxEntries(t, NewWithKeyCompare[int, string](less))
+}
+
+func TestPopMinMax(t *testing.T) {
+	testPopMinMax(t, New[int, string]())
+	testPopMinMax(t, NewWithKeyCompare[int, string](less))
+}
+
+func TestErase(t *testing.T) {
+	testErase(t, New[int, string]())
+	testErase(t, NewWithKeyCompare[int, string](less))
+}
+
+func TestEraseReverse(t *testing.T) {
+	testEraseReverse(t, New[int, string]())
+	testEraseReverse(t, NewWithKeyCompare[int, string](less))
+}
+
+func TestEraseEnd(t *testing.T) {
+	testEraseEnd(t, New[int, string]())
+	testEraseEnd(t, NewWithKeyCompare[int, string](less))
+}
+
+func TestDelRange(t *testing.T) {
+	testDelRange(t, New[int, string]())
+	testDelRange(t, NewWithKeyCompare[int, string](less))
+}
+
+func TestDelBeforeFrom(t *testing.T) {
+	testDelBeforeFrom(t, New[int, string]())
+	testDelBeforeFrom(t, NewWithKeyCompare[int, string](less))
+}
+
+func TestEraseRange(t *testing.T) {
+	testEraseRange(t, New[int, string]())
+	testEraseRange(t, NewWithKeyCompare[int, string](less))
+}
+
+func TestSplit(t *testing.T) {
+	testSplit(t, New[int, string]())
+	testSplit(t, NewWithKeyCompare[int, string](less))
+}
+
+func TestJoin(t *testing.T) {
+	testJoin(t, New[int, string](), New[int, string]())
+	testJoin(t, NewWithKeyCompare[int, string](less), NewWithKeyCompare[int, string](less))
+}
+
+func TestJoinOverlapping(t *testing.T) {
+	testJoinOverlapping(t, New[int, string](), New[int, string]())
+	testJoinOverlapping(t, NewWithKeyCompare[int, string](less), NewWithKeyCompare[int, string](less))
+}
+
+func TestJoinItself(t *testing.T) {
+	tr := New[int, string]()
+	join := func() (r any) {
+		defer func() { r = recover() }()
+		Join(tr, tr)
+		return nil
+	}
+	if r := join(); r != "joining a map with itself" {
+		t.Errorf("wrong panic for an empty map, got %v", r)
+	}
+	tr.Set(1, "x")
+	if r := join(); r != "joining a map with itself" {
+		t.Errorf("wrong panic for a one-element map, got %v", r)
+	}
+	testKeysEqual(t, tr, []int{1})
+}
+
+func TestUnion(t *testing.T) {
+	testUnion(t, New[int, string](), New[int, string]())
+	testUnion(t, NewWithKeyCompare[int, string](less), NewWithKeyCompare[int, string](less))
+}
+
+func TestIntersection(t *testing.T) {
+	testIntersection(t, New[int, string](), New[int, string]())
+	testIntersection(t, NewWithKeyCompare[int, string](less), NewWithKeyCompare[int, string](less))
+}
+
+func TestDifference(t *testing.T) {
+	testDifference(t, New[int, string](), New[int, string]())
+	testDifference(t, NewWithKeyCompare[int, string](less), NewWithKeyCompare[int, string](less))
+}
+
+func TestAll(t *testing.T) {
+	testAll(t, New[int, string]())
+	testAll(t, NewWithKeyCompare[int, string](less))
+}
+
+func TestModifyWhileRanging(t *testing.T) {
+	testModifyWhileRanging(t, New[int, string]())
+	testModifyWhileRanging(t, NewWithKeyCompare[int, string](less))
+}
+
+func TestAllRange(t *testing.T) {
+	testAllRange(t, New[int, string]())
+	testAllRange(t, NewWithKeyCompare[int, string](less))
+}
+
+func TestCollect(t *testing.T) {
+	src := map[int]string{3: "c", 1: "a", 2: "b"}
+	testCollect(t, Collect(maps.All(src)))
+	testCollect(t, CollectWithKeyCompare(maps.All(src), less))
+}
+
+func TestFromSorted(t *testing.T) {
+	keys := []int{1, 2, 2, 3}
+	values := []string{"a", "b", "c", "d"}
+	tr, err := FromSorted(keys, values, KeepLast)
+	testFromSorted(t, tr, err, "c")
+	tr, err = FromSortedWithKeyCompare(keys, values, KeepFirst, less)
+	testFromSorted(t, tr, err, "b")
+	if _, err = FromSorted(keys, values, RejectDuplicates); !errors.Is(err, ErrDuplicateKey) {
+		t.Errorf("duplicates should be rejected, got %v", err)
+	}
+	if _, err = FromSorted([]int{2, 1}, []string{"a", "b"}, KeepLast); !errors.Is(err, ErrUnsorted) {
+		t.Errorf("unsorted input should be rejected, got %v", err)
+	}
+	tr, err = FromSorted[int, string](nil, nil, RejectDuplicates)
+	if err != nil {
+		t.Errorf("empty input should be accepted, got %v", err)
+	}
+	testKeysEqual(t, tr, nil)
+}
+
+func TestCollectSorted(t *testing.T) {
+	for n := 0; n < 300; n++ {
+		seq := func(yield func(int, string) bool) {
+			for i := 0; i < n; i++ {
+				if !yield(i, "x") {
+					return
+				}
+			}
+		}
+		tr, err := CollectSorted(seq, RejectDuplicates)
+		if err != nil {
+			t.Errorf("sorted input should be accepted, got %v", err)
+			return
+		}
+		exp := make([]int, n)
+		for i := range exp {
+			exp[i] = i
+		}
+		testKeysEqual(t, tr, exp)
+		tr.Set(n, "y")
+		tr.Del(0)
+		testKeysEqual(t, tr, append(exp, n)[1:])
+	}
+}
+
+func TestClone(t *testing.T) {
+	testClone(t, New[int, string]())
+	testClone(t, NewWithKeyCompare[int, string](less))
+}
+
+func TestCloneWith(t *testing.T) {
+	tr := New[int, *string]()
+	for i := 0; i < 10; i++ {
+		v := "x"
+		tr.Set(i, &v)
+	}
+	c := tr.CloneWith(func(v *string) *string {
+		copied := *v
+		return &copied
+	})
+	for i := 0; i < 10; i++ {
+		v, _ := tr.Get(i)
+		copied, _ := c.Get(i)
+		if v == copied || *v != *copied {
+			t.Errorf("value of %d should be copied", i)
+		}
+	}
+}
+
 func testNew(t *testing.T, tr *TreeMap[int, string]) {
 	if tr.Len() != 0 {
 		t.Error("count should be zero")
@@ -465,3 +810,938 @@ func testRangeSingle(t *testing.T, tr *TreeMap[int, string]) {
 		t.Error("single element 'b' should be found")
 	}
 }
+
+func testSelect(t *testing.T, tr *TreeMap[int, string]) {
+	if it := tr.Select(0); it.Valid() {
+		t.Error("select should not find anything in an empty map")
+	}
+	for i := 0; i < 20; i++ {
+		tr.Set(i*2, "x")
+	}
+	tr.Del(10)
+	tr.Del(20)
+	exp := []int{0, 2, 4, 6, 8, 12, 14, 16, 18, 22, 24, 26, 28, 30, 32, 34, 36, 38}
+	for i, k := range exp {
+		it := tr.Select(i)
+		if !it.Valid() {
+			t.Errorf("select %d should be valid", i)
+			return
+		}
+		if it.Key() != k {
+			t.Errorf("wrong key selected, expected %d, got %d", k, it.Key())
+		}
+	}
+	if it := tr.Select(len(exp)); it.Valid() {
+		t.Error("select should not find anything out of range")
+	}
+	if it := tr.Select(-1); it.Valid() {
+		t.Error("select should not find anything for negative index")
+	}
+}
+
+func testRank(t *testing.T, tr *TreeMap[int, string]) {
+	if r := tr.Rank(0); r != 0 {
+		t.Errorf("wrong rank in an empty map, expected 0, got %d", r)
+	}
+	for i := 1; i <= 10; i++ {
+		tr.Set(i*10, "x")
+	}
+	tbl := [][2]int{
+		{0, 0},
+		{10, 0},
+		{11, 1},
+		{50, 4},
+		{55, 5},
+		{100, 9},
+		{101, 10},
+	}
+	for _, tb := range tbl {
+		if r := tr.Rank(tb[0]); r != tb[1] {
+			t.Errorf("wrong rank of %d, expected %d, got %d", tb[0], tb[1], r)
+		}
+	}
+}
+
+func testCountRange(t *testing.T, tr *TreeMap[int, string]) {
+	if n := tr.CountRange(0, 100, BoundsClosed); n != 0 {
+		t.Errorf("wrong count in an empty map, expected 0, got %d", n)
+	}
+	for i := 1; i <= 10; i++ {
+		tr.Set(i*10, "x")
+	}
+	tbl := []struct {
+		from, to int
+		bounds   Bounds
+		exp      int
+	}{
+		{10, 50, BoundsClosed, 5},
+		{10, 50, BoundsOpen, 3},
+		{10, 50, BoundsClosedOpen, 4},
+		{10, 50, BoundsOpenClosed, 4},
+		{15, 45, BoundsClosed, 3},
+		{15, 45, BoundsOpen, 3},
+		{0, 1000, BoundsClosed, 10},
+		{50, 50, BoundsClosed, 1},
+		{50, 50, BoundsOpen, 0},
+		{50, 50, BoundsClosedOpen, 0},
+		{60, 50, BoundsClosed, 0},
+	}
+	for _, tb := range tbl {
+		if n := tr.CountRange(tb.from, tb.to, tb.bounds); n != tb.exp {
+			t.Errorf("wrong count of %d..%d with bounds %d, expected %d, got %d", tb.from, tb.to, tb.bounds, tb.exp, n)
+		}
+	}
+}
+
+func testDistance(t *testing.T, tr *TreeMap[int, string]) {
+	if d := tr.Distance(tr.Iterator(), tr.End()); d != 0 {
+		t.Errorf("wrong distance in an empty map, expected 0, got %d", d)
+	}
+	for i := 1; i <= 10; i++ {
+		tr.Set(i*10, "x")
+	}
+	first, last := tr.Range(25, 70)
+	if d := tr.Distance(first, last); d != 5 {
+		t.Errorf("wrong distance, expected 5, got %d", d)
+	}
+	if d := tr.Distance(last, first); d != -5 {
+		t.Errorf("wrong distance, expected -5, got %d", d)
+	}
+	if d := tr.Distance(tr.Iterator(), tr.End()); d != 10 {
+		t.Errorf("wrong distance, expected 10, got %d", d)
+	}
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("distance between iterators of another map should panic")
+		}
+	}()
+	tr.Distance(tr.Iterator(), New[int, string]().End())
+}
+
+func testFloorCeiling(t *testing.T, tr *TreeMap[int, string]) {
+	if _, _, ok := tr.Floor(0); ok {
+		t.Error("floor should not exist in an empty map")
+	}
+	tr.Set(10, "a")
+	tr.Set(20, "b")
+	tr.Set(30, "c")
+
+	type lookup func(int) (int, string, bool)
+	tbl := []struct {
+		name   string
+		lookup lookup
+		key    int
+		exp    int
+		expOK  bool
+	}{
+		{"floor", tr.Floor, 5, 0, false},
+		{"floor", tr.Floor, 10, 10, true},
+		{"floor", tr.Floor, 25, 20, true},
+		{"floor", tr.Floor, 35, 30, true},
+		{"ceiling", tr.Ceiling, 5, 10, true},
+		{"ceiling", tr.Ceiling, 20, 20, true},
+		{"ceiling", tr.Ceiling, 25, 30, true},
+		{"ceiling", tr.Ceiling, 35, 0, false},
+		{"lower", tr.Lower, 10, 0, false},
+		{"lower", tr.Lower, 11, 10, true},
+		{"lower", tr.Lower, 30, 20, true},
+		{"lower", tr.Lower, 35, 30, true},
+		{"higher", tr.Higher, 5, 10, true},
+		{"higher", tr.Higher, 10, 20, true},
+		{"higher", tr.Higher, 29, 30, true},
+		{"higher", tr.Higher, 30, 0, false},
+	}
+	for _, tb := range tbl {
+		k, v, ok := tb.lookup(tb.key)
+		if ok != tb.expOK || k != tb.exp {
+			t.Errorf("wrong %s of %d, expected %d %v, got %d %v", tb.name, tb.key, tb.exp, tb.expOK, k, ok)
+		}
+		if ok {
+			if exp, _ := tr.Get(k); exp != v {
+				t.Errorf("wrong %s value of %d, expected '%s', got '%s'", tb.name, tb.key, exp, v)
+			}
+		}
+	}
+}
+
+func testNeighborIterators(t *testing.T, tr *TreeMap[int, string]) {
+	if tr.FloorIterator(0).Valid() || tr.LowerIterator(0).Valid() {
+		t.Error("iterators should not be valid in an empty map")
+	}
+	tr.Set(10, "a")
+	tr.Set(20, "b")
+	tr.Set(30, "c")
+	if it := tr.FloorIterator(5); it.Valid() {
+		t.Error("floor iterator should not be valid")
+	}
+	if it := tr.LowerIterator(10); it.Valid() {
+		t.Error("lower iterator should not be valid")
+	}
+	if it := tr.FloorIterator(25); !it.Valid() || it.Key() != 20 {
+		t.Error("floor iterator should point to 20")
+	}
+	if it := tr.LowerIterator(20); !it.Valid() || it.Key() != 10 {
+		t.Error("lower iterator should point to 10")
+	}
+	if it := tr.CeilingIterator(20); !it.Valid() || it.Key() != 20 {
+		t.Error("ceiling iterator should point to 20")
+	}
+	if it := tr.HigherIterator(20); !it.Valid() || it.Key() != 30 {
+		t.Error("higher iterator should point to 30")
+	}
+
+	var keys []int
+	for it := tr.FloorIterator(25).Reverse(); it.Valid(); it.Next() {
+		keys = append(keys, it.Key())
+	}
+	if len(keys) != 2 || keys[0] != 20 || keys[1] != 10 {
+		t.Errorf("wrong reverse scan from floor, got %v", keys)
+	}
+	keys = nil
+	for it := tr.FloorIterator(25); it.Valid(); it.Next() {
+		keys = append(keys, it.Key())
+	}
+	if len(keys) != 2 || keys[0] != 20 || keys[1] != 30 {
+		t.Errorf("wrong forward scan from floor, got %v", keys)
+	}
+
+	if it := tr.Iterator(); it.Reverse().Forward() != it {
+		t.Error("conversion should preserve the position")
+	}
+	if it := tr.UpperBound(30); it.Reverse().Valid() || it.Reverse().Forward() != it {
+		t.Error("one-past-the-end should convert to one-before-the-start and back")
+	}
+}
+
+func testMinMaxEntries(t *testing.T, tr *TreeMap[int, string]) {
+	if _, _, ok := tr.Min(); ok {
+		t.Error("min should not exist in an empty map")
+	}
+	if _, _, ok := tr.Max(); ok {
+		t.Error("max should not exist in an empty map")
+	}
+	tr.Set(2, "b")
+	tr.Set(1, "a")
+	tr.Set(3, "c")
+	if k, v, ok := tr.Min(); !ok || k != 1 || v != "a" {
+		t.Errorf("wrong min, expected 1 a, got %d %s", k, v)
+	}
+	if k, v, ok := tr.Max(); !ok || k != 3 || v != "c" {
+		t.Errorf("wrong max, expected 3 c, got %d %s", k, v)
+	}
+	tr.Del(3)
+	if k, _, _ := tr.Max(); k != 2 {
+		t.Errorf("wrong max after deletion, expected 2, got %d", k)
+	}
+	tr.Clear()
+	if _, _, ok := tr.Max(); ok {
+		t.Error("max should not exist in a cleared map")
+	}
+	if tr.Reverse().Valid() {
+		t.Error("reverse iterator should not be valid in a cleared map")
+	}
+}
+
+func testPopMinMax(t *testing.T, tr *TreeMap[int, string]) {
+	if _, _, ok := tr.PopMin(); ok {
+		t.Error("nothing should be popped from an empty map")
+	}
+	if _, _, ok := tr.PopMax(); ok {
+		t.Error("nothing should be popped from an empty map")
+	}
+	for i := 0; i < 10; i++ {
+		tr.Set(i, "x")
+	}
+	for i := 0; i < 5; i++ {
+		if k, _, ok := tr.PopMin(); !ok || k != i {
+			t.Errorf("wrong popped min, expected %d, got %d", i, k)
+		}
+		if k, _, ok := tr.PopMax(); !ok || k != 9-i {
+			t.Errorf("wrong popped max, expected %d, got %d", 9-i, k)
+		}
+		if !treeInvariant(tr.endNode.left) {
+			t.Error("invariant error")
+		}
+	}
+	if tr.Len() != 0 {
+		t.Errorf("wrong count after popping, expected 0, got %d", tr.Len())
+	}
+	if tr.Iterator().Valid() || tr.Reverse().Valid() {
+		t.Error("iterators should not be valid in an empty map")
+	}
+}
+
+func testErase(t *testing.T, tr *TreeMap[int, string]) {
+	for i := 0; i < 20; i++ {
+		tr.Set(i, "x")
+	}
+	it, end := tr.Range(5, 14)
+	for it != end {
+		if it.Key()%2 == 0 {
+			it = tr.Erase(it)
+		} else {
+			it.Next()
+		}
+		if !treeInvariant(tr.endNode.left) {
+			t.Error("invariant error")
+		}
+	}
+	if tr.Len() != 15 {
+		t.Errorf("wrong count after erasing, expected 15, got %d", tr.Len())
+	}
+	for i := 0; i < 20; i++ {
+		if exp := i < 5 || i > 14 || i%2 != 0; tr.Contains(i) != exp {
+			t.Errorf("wrong presence of %d after erasing, expected %v", i, exp)
+		}
+	}
+	for it := tr.Iterator(); it.Valid(); {
+		it = tr.Erase(it)
+	}
+	if tr.Len() != 0 || tr.Iterator().Valid() || tr.Reverse().Valid() {
+		t.Error("map should be empty")
+	}
+}
+
+func testEraseReverse(t *testing.T, tr *TreeMap[int, string]) {
+	for i := 0; i < 20; i++ {
+		tr.Set(i, "x")
+	}
+	var keys []int
+	for it := tr.Reverse(); it.Valid(); {
+		if it.Key()%3 == 0 {
+			it = tr.EraseReverse(it)
+		} else {
+			keys = append(keys, it.Key())
+			it.Next()
+		}
+	}
+	if tr.Len() != len(keys) {
+		t.Errorf("wrong count after erasing, expected %d, got %d", len(keys), tr.Len())
+	}
+	for it := tr.Reverse(); it.Valid(); it.Next() {
+		if it.Key() != keys[0] {
+			t.Errorf("wrong key after erasing, expected %d, got %d", keys[0], it.Key())
+		}
+		keys = keys[1:]
+	}
+	if !treeInvariant(tr.endNode.left) {
+		t.Error("invariant error")
+	}
+}
+
+func testEraseEnd(t *testing.T, tr *TreeMap[int, string]) {
+	tr.Set(0, "a")
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("should have panicked!")
+		}
+	}()
+	tr.Erase(tr.UpperBound(0))
+}
+
+func testKeysEqual(t *testing.T, tr *TreeMap[int, string], exp []int) {
+	var actual []int
+	for it := tr.Iterator(); it.Valid(); it.Next() {
+		actual = append(actual, it.Key())
+	}
+	if len(actual) != len(exp) {
+		t.Errorf("wrong keys, expected %v, got %v", exp, actual)
+		return
+	}
+	for i := range exp {
+		if actual[i] != exp[i] {
+			t.Errorf("wrong keys, expected %v, got %v", exp, actual)
+			return
+		}
+	}
+	if tr.Len() != len(exp) {
+		t.Errorf("wrong count, expected %d, got %d", len(exp), tr.Len())
+	}
+	if !treeInvariant(tr.endNode.left) {
+		t.Error("invariant error")
+	}
+	if len(exp) != 0 {
+		if k, _, _ := tr.Min(); k != exp[0] {
+			t.Errorf("wrong min, expected %d, got %d", exp[0], k)
+		}
+		if k, _, _ := tr.Max(); k != exp[len(exp)-1] {
+			t.Errorf("wrong max, expected %d, got %d", exp[len(exp)-1], k)
+		}
+	}
+}
+
+func testDelRange(t *testing.T, tr *TreeMap[int, string]) {
+	if n := tr.DelRange(0, 10); n != 0 {
+		t.Errorf("wrong number of deleted elements, expected 0, got %d", n)
+	}
+	for i := 0; i < 10; i++ {
+		tr.Set(i, "x")
+	}
+	if n := tr.DelRange(3, 5); n != 3 {
+		t.Errorf("wrong number of deleted elements, expected 3, got %d", n)
+	}
+	testKeysEqual(t, tr, []int{0, 1, 2, 6, 7, 8, 9})
+	if n := tr.DelRange(5, 3); n != 0 {
+		t.Errorf("wrong number of deleted elements, expected 0, got %d", n)
+	}
+	if n := tr.DelRange(20, 30); n != 0 {
+		t.Errorf("wrong number of deleted elements, expected 0, got %d", n)
+	}
+	if n := tr.DelRange(8, 20); n != 2 {
+		t.Errorf("wrong number of deleted elements, expected 2, got %d", n)
+	}
+	testKeysEqual(t, tr, []int{0, 1, 2, 6, 7})
+	if n := tr.DelRange(-10, 10); n != 5 {
+		t.Errorf("wrong number of deleted elements, expected 5, got %d", n)
+	}
+	testKeysEqual(t, tr, nil)
+	tr.Set(1, "x")
+	testKeysEqual(t, tr, []int{1})
+}
+
+func testDelBeforeFrom(t *testing.T, tr *TreeMap[int, string]) {
+	for i := 0; i < 100; i++ {
+		tr.Set(i, "x")
+	}
+	if n := tr.DelBefore(10); n != 10 {
+		t.Errorf("wrong number of deleted elements, expected 10, got %d", n)
+	}
+	if n := tr.DelFrom(15); n != 85 {
+		t.Errorf("wrong number of deleted elements, expected 85, got %d", n)
+	}
+	testKeysEqual(t, tr, []int{10, 11, 12, 13, 14})
+	if n := tr.DelBefore(10); n != 0 {
+		t.Errorf("wrong number of deleted elements, expected 0, got %d", n)
+	}
+	if n := tr.DelFrom(15); n != 0 {
+		t.Errorf("wrong number of deleted elements, expected 0, got %d", n)
+	}
+	testKeysEqual(t, tr, []int{10, 11, 12, 13, 14})
+}
+
+func testEraseRange(t *testing.T, tr *TreeMap[int, string]) {
+	for i := 0; i < 10; i++ {
+		tr.Set(i, "x")
+	}
+	keep := tr.LowerBound(7)
+	if n := tr.EraseRange(tr.LowerBound(2), keep); n != 5 {
+		t.Errorf("wrong number of deleted elements, expected 5, got %d", n)
+	}
+	testKeysEqual(t, tr, []int{0, 1, 7, 8, 9})
+	if keep.Err() == nil {
+		t.Error("range deletion should invalidate iterators")
+	}
+	keep = tr.LowerBound(7)
+	keep.Prev()
+	if keep.Key() != 1 {
+		t.Errorf("wrong key, expected 1, got %d", keep.Key())
+	}
+	if n := tr.EraseRange(keep, keep); n != 0 {
+		t.Errorf("wrong number of deleted elements, expected 0, got %d", n)
+	}
+	if n := tr.EraseRange(tr.UpperBound(7), tr.End()); n != 2 {
+		t.Errorf("wrong number of deleted elements, expected 2, got %d", n)
+	}
+	testKeysEqual(t, tr, []int{0, 1, 7})
+	if n := tr.EraseRange(tr.Iterator(), tr.End()); n != 3 {
+		t.Errorf("wrong number of deleted elements, expected 3, got %d", n)
+	}
+	testKeysEqual(t, tr, nil)
+}
+
+func testSplit(t *testing.T, tr *TreeMap[int, string]) {
+	for i := 0; i < 10; i++ {
+		tr.Set(i, "x")
+	}
+	left, right := tr.Split(4)
+	testKeysEqual(t, left, []int{0, 1, 2, 3})
+	testKeysEqual(t, right, []int{4, 5, 6, 7, 8, 9})
+	testKeysEqual(t, tr, nil)
+	left.Set(-1, "y")
+	right.Set(10, "y")
+	testKeysEqual(t, left, []int{-1, 0, 1, 2, 3})
+	testKeysEqual(t, right, []int{4, 5, 6, 7, 8, 9, 10})
+	l, r := right.Split(100)
+	testKeysEqual(t, l, []int{4, 5, 6, 7, 8, 9, 10})
+	testKeysEqual(t, r, nil)
+	l, r = l.Split(-100)
+	testKeysEqual(t, l, nil)
+	testKeysEqual(t, r, []int{4, 5, 6, 7, 8, 9, 10})
+}
+
+func testJoin(t *testing.T, left, right *TreeMap[int, string]) {
+	for i := 0; i < 3; i++ {
+		left.Set(i, "x")
+	}
+	for i := 10; i < 30; i++ {
+		right.Set(i, "y")
+	}
+	joined := Join(left, right)
+	exp := []int{0, 1, 2}
+	for i := 10; i < 30; i++ {
+		exp = append(exp, i)
+	}
+	testKeysEqual(t, joined, exp)
+	testKeysEqual(t, left, nil)
+	testKeysEqual(t, right, nil)
+	if v, _ := joined.Get(15); v != "y" {
+		t.Errorf("wrong value after join, expected 'y', got '%s'", v)
+	}
+	joined = Join(joined, left)
+	testKeysEqual(t, joined, exp)
+	joined = Join(right, joined)
+	testKeysEqual(t, joined, exp)
+}
+
+func testJoinOverlapping(t *testing.T, left, right *TreeMap[int, string]) {
+	left.Set(5, "x")
+	right.Set(5, "y")
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("should have panicked!")
+		}
+	}()
+	Join(left, right)
+}
+
+func concat(key int, a, b string) string { return a + b }
+
+func testAlgebraOperands(a, b *TreeMap[int, string]) {
+	for i := 0; i < 10; i++ {
+		a.Set(i, "a")
+	}
+	for i := 5; i < 20; i += 2 {
+		b.Set(i, "b")
+	}
+}
+
+func testUnion(t *testing.T, a, b *TreeMap[int, string]) {
+	testKeysEqual(t, Union(a, b, concat), nil)
+	testAlgebraOperands(a, b)
+	u := Union(a, b, concat)
+	testKeysEqual(t, u, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 11, 13, 15, 17, 19})
+	for k, exp := range map[int]string{0: "a", 5: "ab", 6: "a", 9: "ab", 11: "b"} {
+		if v, _ := u.Get(k); v != exp {
+			t.Errorf("wrong value of %d, expected '%s', got '%s'", k, exp, v)
+		}
+	}
+	if a.Len() != 10 || b.Len() != 8 {
+		t.Error("operands should not be modified")
+	}
+	u = Union(a, b, nil)
+	testKeysEqual(t, u, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 11, 13, 15, 17, 19})
+	for k, exp := range map[int]string{5: "a", 11: "b"} {
+		if v, _ := u.Get(k); v != exp {
+			t.Errorf("wrong value of %d without a resolver, expected '%s', got '%s'", k, exp, v)
+		}
+	}
+}
+
+func testIntersection(t *testing.T, a, b *TreeMap[int, string]) {
+	testAlgebraOperands(a, b)
+	i := Intersection(a, b, concat)
+	testKeysEqual(t, i, []int{5, 7, 9})
+	for it := i.Iterator(); it.Valid(); it.Next() {
+		if it.Value() != "ab" {
+			t.Errorf("wrong value of %d, expected 'ab', got '%s'", it.Key(), it.Value())
+		}
+	}
+	i = Intersection(a, b, nil)
+	testKeysEqual(t, i, []int{5, 7, 9})
+	if v, _ := i.Get(7); v != "a" {
+		t.Errorf("wrong value of 7 without a resolver, expected 'a', got '%s'", v)
+	}
+}
+
+func testDifference(t *testing.T, a, b *TreeMap[int, string]) {
+	testAlgebraOperands(a, b)
+	testKeysEqual(t, Difference(a, b), []int{0, 1, 2, 3, 4, 6, 8})
+	testKeysEqual(t, Difference(b, a), []int{11, 13, 15, 17, 19})
+	testKeysEqual(t, SymmetricDifference(a, b), []int{0, 1, 2, 3, 4, 6, 8, 11, 13, 15, 17, 19})
+	testKeysEqual(t, Difference(a, a), nil)
+}
+
+func testAll(t *testing.T, tr *TreeMap[int, string]) {
+	for i := 0; i < 5; i++ {
+		tr.Set(i, string(rune('a'+i)))
+	}
+	var keys []int
+	var values []string
+	for k, v := range tr.All() {
+		keys = append(keys, k)
+		values = append(values, v)
+	}
+	if !slices.Equal(keys, []int{0, 1, 2, 3, 4}) || !slices.Equal(values, []string{"a", "b", "c", "d", "e"}) {
+		t.Errorf("wrong forward iteration, got %v %v", keys, values)
+	}
+	if keys := slices.Collect(tr.Keys()); !slices.Equal(keys, []int{0, 1, 2, 3, 4}) {
+		t.Errorf("wrong keys, got %v", keys)
+	}
+	if values := slices.Collect(tr.Values()); !slices.Equal(values, []string{"a", "b", "c", "d", "e"}) {
+		t.Errorf("wrong values, got %v", values)
+	}
+	keys = nil
+	for k := range tr.Backward() {
+		keys = append(keys, k)
+		if k == 2 {
+			break
+		}
+	}
+	if !slices.Equal(keys, []int{4, 3, 2}) {
+		t.Errorf("wrong backward iteration, got %v", keys)
+	}
+	for k := range tr.All() {
+		if k%2 == 0 {
+			tr.Del(k)
+		}
+	}
+	testKeysEqual(t, tr, []int{1, 3})
+	for k := range tr.Backward() {
+		tr.Del(k)
+	}
+	testKeysEqual(t, tr, nil)
+}
+
+func testAllRange(t *testing.T, tr *TreeMap[int, string]) {
+	for i := 0; i < 10; i++ {
+		tr.Set(i*2, "x")
+	}
+	tbl := []struct {
+		seq func(yield func(int, string) bool)
+		exp []int
+	}{
+		{tr.AllRange(3, 9), []int{4, 6, 8}},
+		{tr.AllRange(4, 8), []int{4, 6, 8}},
+		{tr.AllRange(9, 3), nil},
+		{tr.AllRange(100, 200), nil},
+		{tr.BackwardRange(3, 9), []int{8, 6, 4}},
+		{tr.BackwardRange(4, 8), []int{8, 6, 4}},
+		{tr.BackwardRange(9, 3), nil},
+		{tr.BackwardRange(-10, -1), nil},
+		{tr.BackwardRange(5, 5), nil},
+		{tr.AllFrom(15), []int{16, 18}},
+		{tr.BackwardFrom(3), []int{2, 0}},
+		{tr.BackwardFrom(-1), nil},
+	}
+	for i, tb := range tbl {
+		var keys []int
+		for k := range tb.seq {
+			keys = append(keys, k)
+		}
+		if !slices.Equal(keys, tb.exp) {
+			t.Errorf("wrong keys in range #%d, expected %v, got %v", i, tb.exp, keys)
+		}
+	}
+}
+
+func testCollect(t *testing.T, tr *TreeMap[int, string]) {
+	testKeysEqual(t, tr, []int{1, 2, 3})
+	if values := slices.Collect(tr.Values()); !slices.Equal(values, []string{"a", "b", "c"}) {
+		t.Errorf("wrong values, got %v", values)
+	}
+}
+
+func testFromSorted(t *testing.T, tr *TreeMap[int, string], err error, dup string) {
+	if err != nil {
+		t.Errorf("sorted input should be accepted, got %v", err)
+		return
+	}
+	testKeysEqual(t, tr, []int{1, 2, 3})
+	if v, _ := tr.Get(2); v != dup {
+		t.Errorf("wrong duplicate value, expected '%s', got '%s'", dup, v)
+	}
+}
+
+func sameStructure[Key, Value any](a, b *node[Key, Value]) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.isBlack == b.isBlack && a.size == b.size &&
+		sameStructure(a.left, b.left) && sameStructure(a.right, b.right)
+}
+
+func testClone(t *testing.T, tr *TreeMap[int, string]) {
+	testKeysEqual(t, tr.Clone(), nil)
+	for i := 0; i < 100; i++ {
+		tr.Set(i, "x")
+	}
+	c := tr.Clone()
+	if !sameStructure(tr.endNode.left, c.endNode.left) {
+		t.Error("clone should have the same structure")
+	}
+	c.DelRange(10, 89)
+	c.Set(5, "y")
+	exp := make([]int, 0, 20)
+	for i := 0; i < 100; i++ {
+		if i < 10 || i >= 90 {
+			exp = append(exp, i)
+		}
+	}
+	testKeysEqual(t, c, exp)
+	if tr.Len() != 100 {
+		t.Errorf("original map should not change, got %d elements", tr.Len())
+	}
+	if v, _ := tr.Get(5); v != "x" {
+		t.Errorf("original map should not change, got '%s'", v)
+	}
+}
+
+func testInvalidIterators(t *testing.T, tr *TreeMap[int, string]) {
+	mustPanic := func(name string, f func()) {
+		t.Helper()
+		defer func() {
+			if r := recover(); r == nil {
+				t.Errorf("%s should panic", name)
+			}
+		}()
+		f()
+	}
+	for i := 0; i < 10; i++ {
+		tr.Set(i, "x")
+	}
+	it := tr.LowerBound(5)
+	rit := tr.LowerBound(5).Reverse()
+	other := tr.LowerBound(6)
+	end := tr.End()
+	tr.Set(100, "y")
+	tr.Set(6, "z")
+	if it.Err() != nil || it.Key() != 5 {
+		t.Error("insertion should not invalidate iterators")
+	}
+	tr.Erase(tr.LowerBound(5))
+	if !errors.Is(it.Err(), ErrInvalidIterator) || !errors.Is(rit.Err(), ErrInvalidIterator) {
+		t.Error("iterators pointing to the erased element should be invalidated")
+	}
+	mustPanic("Key", func() { it.Key() })
+	mustPanic("Value", func() { it.Value() })
+	mustPanic("Next", func() { it.Next() })
+	mustPanic("Prev", func() { it.Prev() })
+	mustPanic("Erase", func() { tr.Erase(it) })
+	mustPanic("reverse Key", func() { rit.Key() })
+	mustPanic("reverse Next", func() { rit.Next() })
+	mustPanic("reverse Prev", func() { rit.Prev() })
+	if other.Err() != nil || other.Value() != "z" {
+		t.Error("erasing should not invalidate other iterators")
+	}
+	it = tr.LowerBound(8)
+	tr.DelRange(5, 12)
+	if !errors.Is(it.Err(), ErrInvalidIterator) {
+		t.Error("iterators pointing to the elements deleted by a range deletion should be invalidated")
+	}
+	mustPanic("Next after a range deletion", func() { it.Next() })
+	for i := 6; i < 10; i++ {
+		tr.Set(i, "x")
+	}
+	other = tr.LowerBound(6)
+	tr.Set(6, "z")
+	snapshot := tr.Snapshot()
+	tr.Set(7, "w")
+	mustPanic("Key after a snapshot", func() { other.Key() })
+	if snapshot.Len() != 10 {
+		t.Errorf("wrong snapshot length, expected 10, got %d", snapshot.Len())
+	}
+	it = tr.Iterator()
+	tr.Clear()
+	mustPanic("Key after clearing", func() { it.Key() })
+	if end.Err() != nil || end != tr.End() || tr.Iterator() != end {
+		t.Error("the one-past-the-end iterator should stay valid")
+	}
+	tr.Set(1, "x")
+	it = tr.Iterator()
+	left, _ := tr.Split(1)
+	mustPanic("Key after splitting", func() { it.Key() })
+	tr = left
+	tr.SetIteratorChecks(false)
+	tr.Set(1, "x")
+	it = tr.Iterator()
+	tr.Clear()
+	if it.Err() == nil {
+		t.Error("error should be reported even if checks are off")
+	}
+	if it.Key() != 1 {
+		t.Error("unchecked iterator should not panic")
+	}
+}
+
+func testSetHint(t *testing.T, tr *TreeMap[int, string]) {
+	it := tr.SetHint(tr.End(), 5, "a")
+	if it.Key() != 5 || it.Value() != "a" {
+		t.Errorf("wrong inserted element, got %d %s", it.Key(), it.Value())
+	}
+	it = tr.SetHint(it, 6, "b")
+	it = tr.SetHint(it, 4, "c")
+	it = tr.SetHint(tr.End(), 10, "d")
+	it = tr.SetHint(it, 1, "e")
+	it = tr.SetHint(tr.Iterator(), 7, "f")
+	if it.Key() != 7 || it.Value() != "f" {
+		t.Errorf("wrong inserted element, got %d %s", it.Key(), it.Value())
+	}
+	it = tr.SetHint(tr.LowerBound(6), 6, "g")
+	if it.Key() != 6 || it.Value() != "g" {
+		t.Errorf("wrong updated element, got %d %s", it.Key(), it.Value())
+	}
+	it = tr.SetHint(tr.LowerBound(10), 4, "h")
+	if it.Key() != 4 || it.Value() != "h" {
+		t.Errorf("wrong updated element, got %d %s", it.Key(), it.Value())
+	}
+	testKeysEqual(t, tr, []int{1, 4, 5, 6, 7, 10})
+	var values []string
+	for _, v := range tr.All() {
+		values = append(values, v)
+	}
+	if exp := []string{"e", "h", "a", "g", "f", "d"}; !slices.Equal(values, exp) {
+		t.Errorf("wrong values, expected %v, got %v", exp, values)
+	}
+}
+
+func testInsert(t *testing.T, tr *TreeMap[int, string]) {
+	it, inserted := tr.Insert(1, "a")
+	if !inserted || it.Key() != 1 || it.Value() != "a" {
+		t.Errorf("1 should be inserted, got %d %s %v", it.Key(), it.Value(), inserted)
+	}
+	it, inserted = tr.Insert(1, "b")
+	if inserted || it.Key() != 1 || it.Value() != "b" {
+		t.Errorf("1 should be updated, got %d %s %v", it.Key(), it.Value(), inserted)
+	}
+	if !tr.SetIfAbsent(2, "c") {
+		t.Error("2 should be set")
+	}
+	if tr.SetIfAbsent(2, "d") {
+		t.Error("2 should not be set twice")
+	}
+	if v, _ := tr.Get(2); v != "c" {
+		t.Errorf("wrong value, expected 'c', got '%s'", v)
+	}
+	testKeysEqual(t, tr, []int{1, 2})
+}
+
+func testSwapTake(t *testing.T, tr *TreeMap[int, string]) {
+	if old, existed := tr.Swap(1, "a"); existed || old != "" {
+		t.Errorf("1 should not exist, got '%s'", old)
+	}
+	if old, existed := tr.Swap(1, "b"); !existed || old != "a" {
+		t.Errorf("wrong old value, expected 'a', got '%s'", old)
+	}
+	tr.Set(2, "c")
+	if v, ok := tr.Take(1); !ok || v != "b" {
+		t.Errorf("wrong taken value, expected 'b', got '%s'", v)
+	}
+	if v, ok := tr.Take(1); ok || v != "" {
+		t.Errorf("1 should not exist, got '%s'", v)
+	}
+	testKeysEqual(t, tr, []int{2})
+}
+
+func testUpdate(t *testing.T, tr *TreeMap[int, string]) {
+	tr.Update(1, func(old string, exists bool) (string, bool) {
+		if exists || old != "" {
+			t.Error("1 should not exist")
+		}
+		return "a", true
+	})
+	tr.Update(1, func(old string, exists bool) (string, bool) {
+		if !exists || old != "a" {
+			t.Errorf("wrong old value, expected 'a', got '%s'", old)
+		}
+		return old + "b", true
+	})
+	if v, _ := tr.Get(1); v != "ab" {
+		t.Errorf("wrong value, expected 'ab', got '%s'", v)
+	}
+	tr.Update(2, func(string, bool) (string, bool) { return "c", false })
+	tr.Set(3, "d")
+	tr.Update(3, func(string, bool) (string, bool) {
+		if !tr.Contains(1) {
+			t.Error("reading should work in the update function")
+		}
+		return "", false
+	})
+	testKeysEqual(t, tr, []int{1})
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("modifying the map in the update function should panic")
+		}
+		testKeysEqual(t, tr, []int{1, 5})
+	}()
+	tr.Update(4, func(string, bool) (string, bool) {
+		tr.Set(5, "e")
+		return "f", true
+	})
+}
+
+func testModifyWhileRanging(t *testing.T, tr *TreeMap[int, string]) {
+	fill := func() {
+		tr.Clear()
+		for i := 0; i < 100; i++ {
+			tr.Set(i, "x")
+		}
+	}
+	var exp []int
+	for i := 0; i < 100; i += 2 {
+		exp = append(exp, i)
+	}
+	var expBackward []int
+	for i := 99; i >= 0; i -= 2 {
+		expBackward = append(expBackward, i)
+	}
+	tests := []struct {
+		name string
+		run  func() []int
+		exp  []int
+	}{
+		{"deleting the next key", func() (keys []int) {
+			for k := range tr.All() {
+				keys = append(keys, k)
+				tr.Del(k + 1)
+			}
+			return keys
+		}, exp},
+		{"deleting the next key backward", func() (keys []int) {
+			for k := range tr.Backward() {
+				keys = append(keys, k)
+				tr.Del(k - 1)
+			}
+			return keys
+		}, expBackward},
+		{"deleting the next key in a range", func() (keys []int) {
+			for k := range tr.AllRange(0, 9) {
+				keys = append(keys, k)
+				tr.Del(k + 1)
+			}
+			return keys
+		}, []int{0, 2, 4, 6, 8}},
+		{"deleting the range bound", func() (keys []int) {
+			for k := range tr.AllRange(0, 5) {
+				keys = append(keys, k)
+				tr.Del(6)
+			}
+			return keys
+		}, []int{0, 1, 2, 3, 4, 5}},
+		{"deleting the current and the next keys after a snapshot", func() (keys []int) {
+			tr.Snapshot()
+			for k := range tr.All() {
+				keys = append(keys, k)
+				tr.Del(k)
+				tr.Del(k + 1)
+			}
+			return keys
+		}, exp},
+		{"deleting a range", func() (keys []int) {
+			for k := range tr.Backward() {
+				keys = append(keys, k)
+				tr.DelRange(k-2, k-1)
+			}
+			return keys
+		}, []int{99, 96, 93, 90, 87, 84, 81, 78, 75, 72, 69, 66, 63, 60, 57, 54, 51, 48, 45, 42,
+			39, 36, 33, 30, 27, 24, 21, 18, 15, 12, 9, 6, 3, 0}},
+	}
+	for _, test := range tests {
+		fill()
+		if keys := test.run(); !slices.Equal(keys, test.exp) {
+			t.Errorf("wrong keys when %s, expected %v, got %v", test.name, test.exp, keys)
+		}
+		if !treeInvariant(tr.endNode.left) {
+			t.Errorf("invariant error when %s", test.name)
+		}
+	}
+}